@@ -5,10 +5,12 @@ import (
     "fmt"
     "os"
     "strings"
+    "time"
 
     "github.com/pp00x/foodiebaba/configs"
     "github.com/pp00x/foodiebaba/internal/db"
     "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
     "golang.org/x/crypto/bcrypt"
 )
 
@@ -36,11 +38,25 @@ func main() {
         os.Exit(1)
     }
 
+    // The first account created here owns the site; only an owner can manage admins
+    role := rbac.RoleAdmin
+    var owners int64
+    if err := db.DB.Model(&models.User{}).Where("role = ?", rbac.RoleOwner).Count(&owners).Error; err != nil {
+        fmt.Println("Error checking for an owner:", err)
+        os.Exit(1)
+    }
+    if owners == 0 {
+        role = rbac.RoleOwner
+    }
+
+    verifiedAt := time.Now()
     adminUser := models.User{
-        Username: username,
-        Email:    email,
-        Password: string(hashedPassword),
-        Role:     "admin",
+        Username:        username,
+        Email:           email,
+        Password:        string(hashedPassword),
+        Role:            role,
+        EmailVerified:   true,
+        EmailVerifiedAt: &verifiedAt,
     }
 
     if err := db.DB.Create(&adminUser).Error; err != nil {
@@ -48,5 +64,5 @@ func main() {
         os.Exit(1)
     }
 
-    fmt.Println("Admin user created successfully")
+    fmt.Printf("User created successfully with the %s role\n", role)
 }
\ No newline at end of file
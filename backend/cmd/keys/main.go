@@ -0,0 +1,77 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "text/tabwriter"
+
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/keys"
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+const usage = `Manage the keys that sign JWTs.
+
+Usage:
+  go run ./cmd/keys generate [-alg RS256|EdDSA]   create a new active key; the previous one keeps verifying
+  go run ./cmd/keys list                          show all keys
+  go run ./cmd/keys retire <kid>                  stop a rotated-out key from verifying tokens
+`
+
+func main() {
+    if len(os.Args) < 2 {
+        fmt.Print(usage)
+        os.Exit(2)
+    }
+
+    configs.LoadConfig()
+    db.Init()
+    if err := db.DB.AutoMigrate(&models.SigningKey{}); err != nil {
+        fmt.Println("Migration failed:", err)
+        os.Exit(1)
+    }
+
+    switch os.Args[1] {
+    case "generate":
+        flags := flag.NewFlagSet("generate", flag.ExitOnError)
+        alg := flags.String("alg", "RS256", "signing algorithm: RS256 or EdDSA")
+        flags.Parse(os.Args[2:])
+
+        key, err := keys.Generate(db.DB, *alg)
+        if err != nil {
+            fmt.Println("Error generating key:", err)
+            os.Exit(1)
+        }
+        fmt.Printf("Generated %s key %s. Running servers start signing with it within a minute.\n", key.Algorithm, key.KID)
+
+    case "list":
+        var records []models.SigningKey
+        if err := db.DB.Order("created_at DESC").Find(&records).Error; err != nil {
+            fmt.Println("Error listing keys:", err)
+            os.Exit(1)
+        }
+        w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+        fmt.Fprintln(w, "KID\tALGORITHM\tSTATUS\tCREATED")
+        for _, record := range records {
+            fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", record.KID, record.Algorithm, record.Status, record.CreatedAt.Format("2006-01-02 15:04:05"))
+        }
+        w.Flush()
+
+    case "retire":
+        if len(os.Args) < 3 {
+            fmt.Print(usage)
+            os.Exit(2)
+        }
+        if err := keys.Retire(db.DB, os.Args[2]); err != nil {
+            fmt.Println("Error retiring key:", err)
+            os.Exit(1)
+        }
+        fmt.Printf("Retired key %s. Tokens signed with it are no longer accepted.\n", os.Args[2])
+
+    default:
+        fmt.Print(usage)
+        os.Exit(2)
+    }
+}
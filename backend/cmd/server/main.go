@@ -2,12 +2,21 @@ package main
 
 import (
     "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/accounts"
     "github.com/pp00x/foodiebaba/internal/controllers"
     "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/geocoding"
+    "github.com/pp00x/foodiebaba/internal/keys"
     "github.com/pp00x/foodiebaba/internal/middlewares"
     "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/oidc"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/search"
+    "github.com/pp00x/foodiebaba/internal/taxonomy"
     "github.com/pp00x/foodiebaba/pkg/logger"
+    "github.com/pp00x/foodiebaba/pkg/mailer"
     "net/http"
+    "strings"
      "time"
 
     "github.com/gin-gonic/gin"
@@ -46,26 +55,77 @@ func main() {
 
     // Initialize the logger
     logger.Init()
+    mailer.Init()
+    oidc.Init()
+    if err := geocoding.Init(); err != nil {
+        logger.Log.Fatal("Geocoder setup failed: ", err)
+    }
     err := db.DB.AutoMigrate(
         &models.User{},
         &models.Restaurant{},
         &models.Review{},
         &models.Photo{},
+        &models.RefreshToken{},
+        &models.UserToken{},
+        &models.RecoveryCode{},
+        &models.UserIdentity{},
+        &models.OAuthState{},
+        &models.SigningKey{},
+        &models.APIKey{},
+        &models.LoginThrottle{},
+        &models.RestaurantEdit{},
+        &models.SuggestedEdit{},
+        &models.RestaurantRevision{},
+        &models.OpeningHours{},
+        &models.Tag{},
+        &models.Menu{},
+        &models.MenuSection{},
+        &models.MenuItem{},
     )
 
     if err != nil {
         logger.Log.Fatal("Migration failed: ", err)
     }
 
+    if err := search.Migrate(); err != nil {
+        logger.Log.Fatal("Setting up restaurant search failed: ", err)
+    }
+
+    if err := taxonomy.Seed(); err != nil {
+        logger.Log.Fatal("Seeding tags failed: ", err)
+    }
+    if err := taxonomy.MigrateCategories(); err != nil {
+        logger.Log.Error("Mapping restaurant categories to tags failed: ", err)
+    }
+    if err := controllers.BackfillRevisions(); err != nil {
+        logger.Log.Error("Recording baseline revisions failed: ", err)
+    }
+    if err := accounts.EnsureOwner(); err != nil {
+        logger.Log.Error("Promoting an owner failed: ", err)
+    }
+
+    if err := keys.Load(); err != nil {
+        logger.Log.Fatal("Loading signing keys failed: ", err)
+    }
+    keys.StartRefresh(time.Minute)
+    accounts.StartPurge(time.Hour)
+
 
 
     r := gin.Default()
 
+    // Client IPs feed the login throttle; TRUSTED_PROXIES limits who may set X-Forwarded-For
+    if proxies := configs.GetEnv("TRUSTED_PROXIES", ""); proxies != "" {
+        if err := r.SetTrustedProxies(strings.Split(proxies, ",")); err != nil {
+            logger.Log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+        }
+    }
+
     // Configure CORS
     r.Use(cors.New(cors.Config{
         AllowOrigins:     []string{"http://localhost:5173"},
         AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-        AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+        AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
         ExposeHeaders:    []string{"Content-Length"},
         AllowCredentials: true,
         MaxAge: 12 * time.Hour,
@@ -83,26 +143,96 @@ func main() {
     r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
     // Public routes
+    r.GET("/.well-known/jwks.json", controllers.JWKS)
     r.POST("/register", controllers.Register)
     r.POST("/login", controllers.Login)
+    r.POST("/login/2fa", controllers.LoginTwoFactor)
+    r.GET("/auth/oidc/:provider/login", controllers.OIDCLogin)
+    r.GET("/auth/oidc/:provider/callback", controllers.OIDCCallback)
+    r.POST("/token/refresh", controllers.RefreshToken)
+    r.POST("/logout", controllers.Logout)
+    r.POST("/password/forgot", controllers.ForgotPassword)
+    r.POST("/password/reset", controllers.ResetPassword)
+    r.GET("/verify-email", controllers.VerifyEmail)
     r.GET("/restaurants", controllers.GetRestaurants)
+    r.GET("/restaurants/:id", middlewares.OptionalJWTAuth(), controllers.GetRestaurant)
+    r.GET("/restaurants/:id/history", middlewares.OptionalJWTAuth(), controllers.GetRestaurantHistory)
+    r.GET("/restaurants/:id/menu", middlewares.OptionalJWTAuth(), controllers.GetRestaurantMenu)
+    r.GET("/search", controllers.Search)
+    r.GET("/autocomplete", controllers.Autocomplete)
+    r.GET("/tags", controllers.ListTags)
+    r.GET("/users/:username", controllers.GetUserProfile)
+    r.GET("/users/:username/reviews", controllers.GetUserReviews)
+    r.GET("/users/:username/restaurants", controllers.GetUserRestaurants)
 
     // Protected routes
     auth := r.Group("/")
     auth.Use(middlewares.JWTAuth())
     {
-        auth.POST("/restaurants", controllers.AddRestaurant)
-        auth.POST("/restaurants/:id/photos", controllers.UploadPhotos)
-        auth.POST("/reviews", controllers.AddReview)
+        auth.POST("/verify-email/resend", controllers.ResendVerificationEmail)
+        auth.POST("/2fa/setup", controllers.SetupTwoFactor)
+        auth.POST("/2fa/confirm", controllers.ConfirmTwoFactor)
+        auth.POST("/2fa/disable", controllers.DisableTwoFactor)
+        auth.POST("/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+        auth.GET("/me", controllers.GetMe)
+        auth.PATCH("/me", controllers.UpdateMe)
+        auth.DELETE("/me", controllers.RequestAccountDeletion)
+        auth.POST("/me/deletion/confirm", controllers.ConfirmAccountDeletion)
+        auth.POST("/me/deletion/cancel", controllers.CancelAccountDeletion)
+        auth.GET("/me/export", controllers.ExportAccount)
+        auth.PUT("/me/avatar", controllers.UploadAvatar)
+        auth.POST("/me/password", controllers.ChangePassword)
+        auth.GET("/me/api-keys", controllers.ListAPIKeys)
+        auth.POST("/me/api-keys", controllers.CreateAPIKey)
+        auth.DELETE("/me/api-keys/:id", controllers.RevokeAPIKey)
+        auth.POST("/restaurants", middlewares.VerifiedEmailOnly(), controllers.AddRestaurant)
+        auth.PATCH("/restaurants/:id", middlewares.VerifiedEmailOnly(), controllers.UpdateRestaurant)
+        auth.PUT("/restaurants/:id/tags", middlewares.VerifiedEmailOnly(), controllers.SetRestaurantTags)
+        auth.PUT("/restaurants/:id/hours", middlewares.VerifiedEmailOnly(), controllers.SetOpeningHours)
+        auth.PUT("/restaurants/:id/attributes", middlewares.VerifiedEmailOnly(), controllers.SetRestaurantAttributes)
+        auth.POST("/restaurants/:id/suggestions", middlewares.VerifiedEmailOnly(), controllers.SuggestEdit)
+        auth.POST("/restaurants/:id/photos", middlewares.VerifiedEmailOnly(), controllers.UploadPhotos)
+        auth.POST("/restaurants/:id/menus", middlewares.VerifiedEmailOnly(), controllers.CreateMenu)
+        auth.PUT("/menus/:id", middlewares.VerifiedEmailOnly(), controllers.UpdateMenu)
+        auth.DELETE("/menus/:id", middlewares.VerifiedEmailOnly(), controllers.DeleteMenu)
+        auth.POST("/menus/:id/sections", middlewares.VerifiedEmailOnly(), controllers.CreateMenuSection)
+        auth.PUT("/menu-sections/:id", middlewares.VerifiedEmailOnly(), controllers.UpdateMenuSection)
+        auth.DELETE("/menu-sections/:id", middlewares.VerifiedEmailOnly(), controllers.DeleteMenuSection)
+        auth.POST("/menu-sections/:id/items", middlewares.VerifiedEmailOnly(), controllers.CreateMenuItem)
+        auth.PUT("/menu-items/:id", middlewares.VerifiedEmailOnly(), controllers.UpdateMenuItem)
+        auth.DELETE("/menu-items/:id", middlewares.VerifiedEmailOnly(), controllers.DeleteMenuItem)
+        auth.PUT("/menu-items/:id/photo", middlewares.VerifiedEmailOnly(), controllers.UploadMenuItemPhoto)
+        auth.POST("/reviews", middlewares.VerifiedEmailOnly(), controllers.AddReview)
     }
 
     // Admin routes
     admin := r.Group("/admin")
-    admin.Use(middlewares.JWTAuth(), middlewares.AdminOnly())
+    admin.Use(middlewares.JWTAuth())
     {
-        admin.GET("/restaurants/pending", controllers.GetPendingRestaurants)
-        admin.PUT("/restaurants/:id/approve", controllers.ApproveRestaurant)
-        admin.PUT("/restaurants/:id/reject", controllers.RejectRestaurant)
+        admin.GET("/restaurants/pending", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.GetPendingRestaurants)
+        admin.PUT("/restaurants/:id/approve", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.ApproveRestaurant)
+        admin.PUT("/restaurants/:id/reject", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.RejectRestaurant)
+        admin.GET("/restaurants/flagged", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.GetFlaggedRestaurants)
+        admin.PUT("/restaurants/:id/location", middlewares.RequirePermission(rbac.RestaurantsEdit), controllers.SetRestaurantLocation)
+        admin.POST("/restaurants/:id/revisions/:revision/rollback", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.RollbackRestaurant)
+        admin.GET("/restaurants/edits", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.GetPendingRestaurantEdits)
+        admin.PUT("/restaurants/edits/:id/approve", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.ApproveRestaurantEdit)
+        admin.PUT("/restaurants/edits/:id/reject", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.RejectRestaurantEdit)
+        admin.GET("/restaurants/suggestions", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.GetPendingSuggestions)
+        admin.PUT("/restaurants/suggestions/:id/approve", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.ApproveSuggestion)
+        admin.PUT("/restaurants/suggestions/:id/reject", middlewares.RequirePermission(rbac.RestaurantsApprove), controllers.RejectSuggestion)
+        admin.POST("/tags", middlewares.RequirePermission(rbac.TagsManage), controllers.CreateTag)
+        admin.PATCH("/tags/:id", middlewares.RequirePermission(rbac.TagsManage), controllers.UpdateTag)
+        admin.DELETE("/tags/:id", middlewares.RequirePermission(rbac.TagsManage), controllers.DeleteTag)
+        admin.DELETE("/reviews/:id", middlewares.RequirePermission(rbac.ReviewsDelete), controllers.DeleteReview)
+        admin.GET("/roles", middlewares.RequirePermission(rbac.UsersRoles), controllers.ListRoles)
+        admin.GET("/users", middlewares.RequirePermission(rbac.UsersView), controllers.ListUsers)
+        admin.GET("/users/:id", middlewares.RequirePermission(rbac.UsersView), controllers.GetUserActivity)
+        admin.POST("/users/:id/suspend", middlewares.RequirePermission(rbac.UsersBan), controllers.SuspendUser)
+        admin.POST("/users/:id/ban", middlewares.RequirePermission(rbac.UsersBan), controllers.BanUser)
+        admin.POST("/users/:id/reinstate", middlewares.RequirePermission(rbac.UsersBan), controllers.ReinstateUser)
+        admin.PUT("/users/:id/role", middlewares.RequirePermission(rbac.UsersRoles), controllers.UpdateUserRole)
+        admin.POST("/users/:id/unlock", middlewares.RequirePermission(rbac.UsersBan), controllers.UnlockUser)
     }
 
     // Health check
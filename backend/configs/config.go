@@ -2,6 +2,9 @@ package configs
 
 import (
     "log"
+    "os"
+    "strconv"
+    "time"
 
     "github.com/joho/godotenv"
 )
@@ -11,4 +14,39 @@ func LoadConfig() {
     if err != nil {
         log.Println("Error loading .env file")
     }
-}
\ No newline at end of file
+}
+
+// GetEnv returns the value of the environment variable or the fallback when it is unset
+func GetEnv(key, fallback string) string {
+    if value, ok := os.LookupEnv(key); ok && value != "" {
+        return value
+    }
+    return fallback
+}
+
+// GetDuration parses a duration such as "15m" from the environment
+func GetDuration(key string, fallback time.Duration) time.Duration {
+    value, err := time.ParseDuration(os.Getenv(key))
+    if err != nil || value <= 0 {
+        return fallback
+    }
+    return value
+}
+
+// GetInt parses an integer from the environment
+func GetInt(key string, fallback int) int {
+    value, err := strconv.Atoi(os.Getenv(key))
+    if err != nil {
+        return fallback
+    }
+    return value
+}
+
+// GetBool parses a boolean such as "true" or "1" from the environment
+func GetBool(key string, fallback bool) bool {
+    value, err := strconv.ParseBool(os.Getenv(key))
+    if err != nil {
+        return fallback
+    }
+    return value
+}
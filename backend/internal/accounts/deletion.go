@@ -0,0 +1,107 @@
+package accounts
+
+import (
+    "fmt"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "os"
+    "strings"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// DeletionGracePeriod is how long a deletion request can be cancelled before the account is anonymized
+func DeletionGracePeriod() time.Duration {
+    return configs.GetDuration("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour)
+}
+
+// Anonymize removes the user's personal data and credentials. Reviews, listings
+// and photos stay in place but point at an anonymous, soft deleted user row.
+func Anonymize(tx *gorm.DB, user models.User) error {
+    now := time.Now()
+    if err := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", now).Error; err != nil {
+        return err
+    }
+    if err := tx.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Update("revoked_at", now).Error; err != nil {
+        return err
+    }
+    if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserToken{}).Error; err != nil {
+        return err
+    }
+    if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+        return err
+    }
+    if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+        return err
+    }
+    if err := tx.Where("key = ?", "account:"+strings.ToLower(user.Email)).Delete(&models.LoginThrottle{}).Error; err != nil {
+        return err
+    }
+
+    err := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+        "username":               fmt.Sprintf("deleted-user-%d", user.ID),
+        "email":                  fmt.Sprintf("deleted-user-%d@deleted.invalid", user.ID),
+        "password":               "",
+        "email_verified":         false,
+        "email_verified_at":      nil,
+        "totp_enabled":           false,
+        "totp_secret":            "",
+        "display_name":           "",
+        "bio":                    "",
+        "avatar_url":             "",
+        "home_city":              "",
+        "dietary_preferences":    nil,
+        "deletion_scheduled_for": nil,
+        "deleted_at":             now,
+    }).Error
+    if err != nil {
+        return err
+    }
+
+    if path, ok := UploadPath(user.AvatarURL); ok {
+        if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+            logger.Log.Error("Error removing avatar: ", err)
+        }
+    }
+    return nil
+}
+
+// PurgeDue anonymizes every account whose deletion grace period has ended
+func PurgeDue() error {
+    var users []models.User
+    if err := db.DB.Where("deletion_scheduled_for <= ?", time.Now()).Find(&users).Error; err != nil {
+        return err
+    }
+    // one account failing must not hold up the others; it is retried on the next run
+    for _, user := range users {
+        if err := db.DB.Transaction(func(tx *gorm.DB) error { return Anonymize(tx, user) }); err != nil {
+            logger.Log.Errorf("Error deleting account of user %d: %v", user.ID, err)
+            continue
+        }
+        logger.Log.Infof("Deleted account of user %d", user.ID)
+    }
+    return nil
+}
+
+// StartPurge deletes accounts whose grace period has ended at every interval
+func StartPurge(interval time.Duration) {
+    go func() {
+        for range time.Tick(interval) {
+            if err := PurgeDue(); err != nil {
+                logger.Log.Error("Error deleting accounts: ", err)
+            }
+        }
+    }()
+}
+
+// UploadPath maps an uploaded file URL such as "/uploads/1_a.jpg" to its path on disk
+func UploadPath(url string) (string, bool) {
+    path := strings.TrimPrefix(url, "/")
+    if !strings.HasPrefix(path, "uploads/") || strings.Contains(path, "..") {
+        return "", false
+    }
+    return path, true
+}
@@ -0,0 +1,33 @@
+package accounts
+
+import (
+    "errors"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+
+    "gorm.io/gorm"
+)
+
+// EnsureOwner promotes the oldest admin to owner when there is no owner yet. Only owners
+// can manage admins, so without one no admin could ever be granted, revoked or banned.
+func EnsureOwner() error {
+    var count int64
+    if err := db.DB.Model(&models.User{}).Where("role = ?", rbac.RoleOwner).Count(&count).Error; err != nil || count > 0 {
+        return err
+    }
+    var admin models.User
+    err := db.DB.Where("role = ? AND banned_at IS NULL", rbac.RoleAdmin).Order("created_at, id").First(&admin).Error
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    if err := db.DB.Model(&admin).Update("role", rbac.RoleOwner).Error; err != nil {
+        return err
+    }
+    logger.Log.Infof("Promoted admin %s to owner because the site had no owner", admin.Username)
+    return nil
+}
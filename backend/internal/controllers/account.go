@@ -0,0 +1,306 @@
+package controllers
+
+import (
+    "archive/zip"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/accounts"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "github.com/pp00x/foodiebaba/pkg/mailer"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "path/filepath"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+// RequestAccountDeletion godoc
+// @Summary      Delete the current user's account
+// @Description  Schedule the account for deletion after a grace period. Until then the user can log in and cancel. When the grace period ends personal data and credentials are removed and reviews are kept anonymously; restaurant listings stay in place. Passwordless accounts created through an identity provider are emailed a confirmation link instead, to be completed with /me/deletion/confirm.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body      models.DeleteAccountInput true "Current password"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me [delete]
+func RequestAccountDeletion(c *gin.Context) {
+    var input models.DeleteAccountInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+
+    if user.Passwordless {
+        ttl := configs.GetDuration("ACCOUNT_DELETION_CONFIRM_TTL", time.Hour)
+        token, err := createUserToken(db.DB, user.ID, models.TokenPurposeAccountDeletion, ttl)
+        if err != nil {
+            logger.Log.Error("Error creating account deletion token: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+            return
+        }
+        link := frontendURL("/confirm-account-deletion?token=" + url.QueryEscape(token))
+        err = mailer.Send(mailer.Message{
+            To:      user.Email,
+            Subject: "Confirm the deletion of your FoodieBaba account",
+            Body: fmt.Sprintf("Hi %s,\n\nUse the link below to confirm that your account should be deleted. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask to delete your account you can ignore this email.\n",
+                user.Username, ttl, link),
+        })
+        if err != nil {
+            logger.Log.Error("Error sending account deletion email: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send the confirmation email"})
+            return
+        }
+        c.JSON(http.StatusAccepted, gin.H{"message": "Check your email to confirm the deletion of your account"})
+        return
+    }
+
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+        logger.Log.Warnf("Invalid password for account deletion of user: %d", user.ID)
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+        return
+    }
+    scheduleAccountDeletion(c, user)
+}
+
+// ConfirmAccountDeletion godoc
+// @Summary      Confirm account deletion
+// @Description  Passwordless accounts schedule their deletion with the token emailed by DELETE /me
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body      models.ConfirmAccountDeletionInput true "Emailed token"
+// @Success      202  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/deletion/confirm [post]
+func ConfirmAccountDeletion(c *gin.Context) {
+    var input models.ConfirmAccountDeletionInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        token, err := consumeUserToken(tx, input.Token, models.TokenPurposeAccountDeletion)
+        if err != nil {
+            return err
+        }
+        // the token only confirms the deletion of the account it was sent to
+        if token.UserID != c.GetUint("userID") {
+            return gorm.ErrRecordNotFound
+        }
+        return tx.First(&user, token.UserID).Error
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired confirmation token"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error confirming account deletion: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+        return
+    }
+    scheduleAccountDeletion(c, user)
+}
+
+// scheduleAccountDeletion starts the grace period after which the account is anonymized
+func scheduleAccountDeletion(c *gin.Context, user models.User) {
+    now := time.Now()
+    scheduledFor := now.Add(accounts.DeletionGracePeriod())
+    if err := db.DB.Model(&user).Updates(map[string]interface{}{
+        "deletion_requested_at":  now,
+        "deletion_scheduled_for": scheduledFor,
+    }).Error; err != nil {
+        logger.Log.Error("Error scheduling account deletion: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+        return
+    }
+
+    logger.Log.Infof("User %d requested account deletion, scheduled for %s", user.ID, scheduledFor.Format(time.RFC3339))
+    c.JSON(http.StatusAccepted, gin.H{
+        "message":       "Account scheduled for deletion",
+        "scheduled_for": scheduledFor,
+    })
+}
+
+// CancelAccountDeletion godoc
+// @Summary      Cancel account deletion
+// @Description  Keep the account when its deletion grace period has not ended yet
+// @Tags         Profile
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/deletion/cancel [post]
+func CancelAccountDeletion(c *gin.Context) {
+    result := db.DB.Model(&models.User{}).
+        Where("id = ? AND deletion_scheduled_for IS NOT NULL", c.GetUint("userID")).
+        Updates(map[string]interface{}{"deletion_requested_at": nil, "deletion_scheduled_for": nil})
+    if result.Error != nil {
+        logger.Log.Error("Error cancelling account deletion: ", result.Error)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Account is not scheduled for deletion"})
+        return
+    }
+
+    logger.Log.Infof("User %d cancelled account deletion", c.GetUint("userID"))
+    c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
+
+// ExportAccount godoc
+// @Summary      Export personal data
+// @Description  Download a zip archive with everything stored about the current user as JSON, including their listings, reviews, edits, suggestions and revisions, plus their avatar and uploaded photos
+// @Tags         Profile
+// @Security     BearerAuth
+// @Produce      application/zip
+// @Success      200  {file}    file
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/export [get]
+func ExportAccount(c *gin.Context) {
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+
+    listings := []models.Restaurant{}
+    reviews := []models.Review{}
+    photos := []models.Photo{}
+    apiKeys := []models.APIKey{}
+    identities := []models.UserIdentity{}
+    sessions := []models.RefreshToken{}
+    edits := []models.RestaurantEdit{}
+    suggestions := []models.SuggestedEdit{}
+    revisions := []models.RestaurantRevision{}
+    err := db.DB.Where("created_by_id = ?", user.ID).Find(&listings).Error
+    if err == nil {
+        err = db.DB.Where("user_id = ?", user.ID).Find(&reviews).Error
+    }
+    if err == nil {
+        err = db.DB.Where("uploaded_by_id = ?", user.ID).Find(&photos).Error
+    }
+    if err == nil {
+        err = db.DB.Where("user_id = ?", user.ID).Find(&apiKeys).Error
+    }
+    if err == nil {
+        err = db.DB.Where("user_id = ?", user.ID).Find(&identities).Error
+    }
+    if err == nil {
+        err = db.DB.Where("user_id = ?", user.ID).Find(&sessions).Error
+    }
+    if err == nil {
+        err = db.DB.Where("edited_by_id = ?", user.ID).Find(&edits).Error
+    }
+    if err == nil {
+        err = db.DB.Where("suggested_by_id = ?", user.ID).Find(&suggestions).Error
+    }
+    if err == nil {
+        err = db.DB.Where("author_id = ?", user.ID).Find(&revisions).Error
+    }
+    if err != nil {
+        logger.Log.Error("Error exporting account: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account"})
+        return
+    }
+
+    data, err := json.MarshalIndent(gin.H{
+        "exported_at":          time.Now(),
+        "user":                 user,
+        "restaurants":          listings,
+        "reviews":              reviews,
+        "photos":               photos,
+        "api_keys":             apiKeys,
+        "identities":           identities,
+        "sessions":             sessions,
+        "restaurant_edits":     edits,
+        "suggested_edits":      suggestions,
+        "restaurant_revisions": revisions,
+    }, "", "  ")
+    if err != nil {
+        logger.Log.Error("Error exporting account: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export account"})
+        return
+    }
+
+    c.Header("Content-Type", "application/zip")
+    c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"foodiebaba-%s.zip\"", user.Username))
+    c.Status(http.StatusOK)
+
+    archive := zip.NewWriter(c.Writer)
+    defer archive.Close()
+    w, err := archive.Create("account.json")
+    if err == nil {
+        _, err = w.Write(data)
+    }
+    if err != nil {
+        logger.Log.Error("Error writing export: ", err)
+        return
+    }
+    if user.AvatarURL != "" {
+        addUploadToArchive(archive, user.AvatarURL, "avatar")
+    }
+    for _, photo := range photos {
+        addUploadToArchive(archive, photo.URL, "photos")
+    }
+}
+
+// addUploadToArchive copies an uploaded file into the archive directory. Files
+// missing from disk are skipped since the JSON still lists their URLs.
+func addUploadToArchive(archive *zip.Writer, url, dir string) {
+    path, ok := accounts.UploadPath(url)
+    if !ok {
+        return
+    }
+    file, err := os.Open(path)
+    if err != nil {
+        if !os.IsNotExist(err) {
+            logger.Log.Error("Error opening upload for export: ", err)
+        }
+        return
+    }
+    defer file.Close()
+
+    w, err := archive.Create(dir + "/" + filepath.Base(path))
+    if err == nil {
+        _, err = io.Copy(w, file)
+    }
+    if err != nil {
+        logger.Log.Error("Error writing upload to export: ", err)
+    }
+}
@@ -0,0 +1,390 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+type updateRoleInput struct {
+    Role string `json:"role" binding:"required"`
+}
+
+// findManagedUser loads the user from the id path parameter and checks the
+// caller may act on them: nobody manages themselves and only owners manage admins
+func findManagedUser(c *gin.Context) (models.User, bool) {
+    var user models.User
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid user ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return user, false
+    }
+    if uint(id) == c.GetUint("userID") {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot do this to your own account"})
+        return user, false
+    }
+    if err := db.DB.First(&user, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return user, false
+    }
+    if rbac.IsPrivileged(user.Role) && !rbac.Can(c.GetString("userRole"), rbac.AdminsManage) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can manage admins"})
+        return user, false
+    }
+    return user, true
+}
+
+// ListUsers godoc
+// @Summary      Search users
+// @Description  Search and paginate users. The total number of matches is returned in the X-Total-Count header.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        q       query     string false  "Search username, email or display name"
+// @Param        role    query     string false  "Filter by role"
+// @Param        status  query     string false  "Filter by status (active, suspended, banned)"
+// @Param        page    query     int    false  "Page number"
+// @Param        limit   query     int    false  "Page size"
+// @Success      200     {array}   models.User
+// @Failure      500     {object}  map[string]string
+// @Router       /admin/users [get]
+func ListUsers(c *gin.Context) {
+    _, limit, offset := pagination(c)
+
+    query := db.DB.Model(&models.User{})
+    if q := c.Query("q"); q != "" {
+        like := "%" + q + "%"
+        query = query.Where("username ILIKE ? OR email ILIKE ? OR display_name ILIKE ?", like, like, like)
+    }
+    if role := c.Query("role"); role != "" {
+        query = query.Where("role = ?", role)
+    }
+    switch c.Query("status") {
+    case models.AccountBanned:
+        query = query.Where("banned_at IS NOT NULL")
+    case models.AccountSuspended:
+        query = query.Where("banned_at IS NULL AND suspended_until > ?", time.Now())
+    case models.AccountActive:
+        query = query.Where("banned_at IS NULL AND (suspended_until IS NULL OR suspended_until <= ?)", time.Now())
+    }
+
+    var total int64
+    if err := query.Count(&total).Error; err != nil {
+        logger.Log.Error("Error counting users: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+        return
+    }
+    users := []models.User{}
+    if err := query.Order("id").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+        logger.Log.Error("Error fetching users: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+        return
+    }
+
+    c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+    c.JSON(http.StatusOK, users)
+}
+
+// GetUserActivity godoc
+// @Summary      View a user's activity
+// @Description  Get a user with their listing counts by status, recent listings and reviews, API keys and login lockout state
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id} [get]
+func GetUserActivity(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid user ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+    var user models.User
+    if err := db.DB.First(&user, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+
+    var listingCounts []struct {
+        Status string
+        Count  int64
+    }
+    var reviewCount, apiKeyCount int64
+    recentListings := []models.Restaurant{}
+    recentReviews := []models.Review{}
+    err = db.DB.Model(&models.Restaurant{}).Select("status, COUNT(*) AS count").Where("created_by_id = ?", user.ID).Group("status").Scan(&listingCounts).Error
+    if err == nil {
+        err = db.DB.Model(&models.Review{}).Where("user_id = ?", user.ID).Count(&reviewCount).Error
+    }
+    if err == nil {
+        err = db.DB.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", user.ID).Count(&apiKeyCount).Error
+    }
+    if err == nil {
+        err = db.DB.Where("created_by_id = ?", user.ID).Order("created_at DESC").Limit(10).Find(&recentListings).Error
+    }
+    if err == nil {
+        err = db.DB.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(10).Find(&recentReviews).Error
+    }
+    if err != nil {
+        logger.Log.Error("Error fetching user activity: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user activity"})
+        return
+    }
+
+    listings := map[string]int64{}
+    for _, row := range listingCounts {
+        listings[row.Status] = row.Count
+    }
+    var throttle *models.LoginThrottle
+    var record models.LoginThrottle
+    if db.DB.Where("key = ?", accountThrottleKey(user.Email)).First(&record).Error == nil {
+        throttle = &record
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "user":            user,
+        "status":          user.AccountStatus(),
+        "listings":        listings,
+        "review_count":    reviewCount,
+        "api_keys":        apiKeyCount,
+        "recent_listings": recentListings,
+        "recent_reviews":  recentReviews,
+        "login_throttle":  throttle,
+    })
+}
+
+// SuspendUser godoc
+// @Summary      Suspend a user
+// @Description  Block a user for a duration such as "72h". Their sessions are revoked and existing tokens stop working.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int                      true  "User ID"
+// @Param        body body      models.SuspendUserInput  true  "Duration and reason"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/suspend [post]
+func SuspendUser(c *gin.Context) {
+    var input models.SuspendUserInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    duration, err := time.ParseDuration(input.Duration)
+    if err != nil || duration <= 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a positive duration such as \"72h\""})
+        return
+    }
+
+    user, ok := findManagedUser(c)
+    if !ok {
+        return
+    }
+    until := time.Now().Add(duration)
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&user).Updates(map[string]interface{}{"suspended_until": until, "suspension_reason": input.Reason}).Error; err != nil {
+            return err
+        }
+        return revokeUserSessions(tx, user.ID)
+    })
+    if err != nil {
+        logger.Log.Error("Error suspending user: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+        return
+    }
+
+    logger.Log.Infof("User %d suspended user %d until %s: %s", c.GetUint("userID"), user.ID, until.Format(time.RFC3339), input.Reason)
+    c.JSON(http.StatusOK, gin.H{"message": "User suspended", "until": until})
+}
+
+// BanUser godoc
+// @Summary      Ban a user
+// @Description  Permanently block a user. Their sessions are revoked and existing tokens stop working.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int                 true  "User ID"
+// @Param        body body      models.BanUserInput true  "Reason"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/ban [post]
+func BanUser(c *gin.Context) {
+    var input models.BanUserInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    user, ok := findManagedUser(c)
+    if !ok {
+        return
+    }
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&user).Updates(map[string]interface{}{"banned_at": time.Now(), "ban_reason": input.Reason}).Error; err != nil {
+            return err
+        }
+        return revokeUserSessions(tx, user.ID)
+    })
+    if err != nil {
+        logger.Log.Error("Error banning user: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+        return
+    }
+
+    logger.Log.Infof("User %d banned user %d: %s", c.GetUint("userID"), user.ID, input.Reason)
+    c.JSON(http.StatusOK, gin.H{"message": "User banned"})
+}
+
+// ReinstateUser godoc
+// @Summary      Reinstate a user
+// @Description  Lift a suspension or ban
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/reinstate [post]
+func ReinstateUser(c *gin.Context) {
+    user, ok := findManagedUser(c)
+    if !ok {
+        return
+    }
+    if err := db.DB.Model(&user).Updates(map[string]interface{}{
+        "suspended_until":   nil,
+        "suspension_reason": "",
+        "banned_at":         nil,
+        "ban_reason":        "",
+    }).Error; err != nil {
+        logger.Log.Error("Error reinstating user: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reinstate user"})
+        return
+    }
+
+    logger.Log.Infof("User %d reinstated user %d", c.GetUint("userID"), user.ID)
+    c.JSON(http.StatusOK, gin.H{"message": "User reinstated"})
+}
+
+// ListRoles godoc
+// @Summary      List roles
+// @Description  Get every role with the permissions it grants
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  map[string][]string
+// @Router       /admin/roles [get]
+func ListRoles(c *gin.Context) {
+    c.JSON(http.StatusOK, rbac.Roles())
+}
+
+// UpdateUserRole godoc
+// @Summary      Change a user's role
+// @Description  Assign a role to a user. Granting or revoking the admin and owner roles requires the admins.manage permission.
+// @Tags         Admin
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int             true  "User ID"
+// @Param        body body      updateRoleInput true  "Role"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/role [put]
+func UpdateUserRole(c *gin.Context) {
+    var input updateRoleInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !rbac.ValidRole(input.Role) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+        return
+    }
+
+    user, ok := findManagedUser(c)
+    if !ok {
+        return
+    }
+    if rbac.IsPrivileged(input.Role) && !rbac.Can(c.GetString("userRole"), rbac.AdminsManage) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "Only owners can grant or revoke admin roles"})
+        return
+    }
+
+    if err := db.DB.Model(&user).Update("role", input.Role).Error; err != nil {
+        logger.Log.Error("Error updating user role: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+        return
+    }
+
+    logger.Log.Infof("User %d changed role of user %d from %s to %s", c.GetUint("userID"), user.ID, user.Role, input.Role)
+    c.JSON(http.StatusOK, gin.H{"message": "Role updated"})
+}
+
+// UnlockUser godoc
+// @Summary      Unlock a user's login
+// @Description  Clear failed login attempts and any temporary lockout on the user's account
+// @Tags         Admin
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "User ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /admin/users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid user ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if err := resetLoginThrottle(accountThrottleKey(user.Email)); err != nil {
+        logger.Log.Error("Error unlocking user: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+        return
+    }
+
+    logger.Log.Infof("User %d unlocked login of user %d", c.GetUint("userID"), user.ID)
+    c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
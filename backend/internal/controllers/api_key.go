@@ -0,0 +1,145 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+// ListAPIKeys godoc
+// @Summary      List API keys
+// @Description  Get the logged in user's API keys. Secrets are never returned after creation.
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   models.APIKey
+// @Failure      500  {object}  map[string]string
+// @Router       /me/api-keys [get]
+func ListAPIKeys(c *gin.Context) {
+    var keys []models.APIKey
+    if err := db.DB.Where("user_id = ?", c.GetUint("userID")).Order("created_at DESC").Find(&keys).Error; err != nil {
+        logger.Log.Error("Error fetching API keys: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+        return
+    }
+    c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Create a personal API key. Send it as "Authorization: Bearer <key>" or "X-API-Key: <key>". The key is only shown once. Keys can only call listing, review and admin routes, never account, session or two-factor routes, and do not count as a two-factor login. Scopes limit a key to some of listings.write, reviews.write and the permissions of the user's role.
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        key  body      models.CreateAPIKeyInput true "API key"
+// @Success      201  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/api-keys [post]
+func CreateAPIKey(c *gin.Context) {
+    // Keys cannot mint more keys
+    if _, usingKey := c.Get("apiKeyID"); usingKey {
+        c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot manage API keys"})
+        return
+    }
+
+    var input models.CreateAPIKeyInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    role := c.GetString("userRole")
+    for _, scope := range input.Scopes {
+        if !rbac.ValidPermission(scope) && !rbac.ValidScope(role, scope) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown scope: " + scope})
+            return
+        }
+        if !rbac.ValidScope(role, scope) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Your role does not grant scope: " + scope})
+            return
+        }
+    }
+    if input.ExpiresAt != nil && input.ExpiresAt.Before(time.Now()) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+        return
+    }
+
+    key, prefix, err := utils.GenerateAPIKey()
+    if err != nil {
+        logger.Log.Error("Error generating API key: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    apiKey := models.APIKey{
+        UserID:    c.GetUint("userID"),
+        Name:      input.Name,
+        Prefix:    prefix,
+        KeyHash:   utils.HashToken(key),
+        Scopes:    input.Scopes,
+        ExpiresAt: input.ExpiresAt,
+    }
+    if apiKey.Scopes == nil {
+        apiKey.Scopes = []string{}
+    }
+    if err := db.DB.Create(&apiKey).Error; err != nil {
+        logger.Log.Error("Error creating API key: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+        return
+    }
+
+    logger.Log.Infof("API key %s created by user %d", prefix, apiKey.UserID)
+    c.JSON(http.StatusCreated, gin.H{"api_key": apiKey, "key": key})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Permanently disable one of the logged in user's API keys
+// @Tags         API Keys
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id   path      int  true  "API key ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /me/api-keys/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+    if _, usingKey := c.Get("apiKeyID"); usingKey {
+        c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot manage API keys"})
+        return
+    }
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid API key ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+        return
+    }
+
+    result := db.DB.Model(&models.APIKey{}).
+        Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, c.GetUint("userID")).
+        Update("revoked_at", time.Now())
+    if result.Error != nil {
+        logger.Log.Error("Error revoking API key: ", result.Error)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/middlewares"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+)
+
+// SetRestaurantAttributes godoc
+// @Summary      Set price level, dietary options and amenities
+// @Description  Replace a restaurant's price level (1 to 4, null when unknown), dietary options and amenities. Only the creator of the listing and users with the restaurants.edit permission can do this; changes to approved listings are queued for moderation unless made by a moderator.
+// @Tags         Restaurants
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                        true  "Restaurant ID"
+// @Param        attributes  body      models.SetAttributesInput  true  "Attributes"
+// @Success      200         {object}  models.Restaurant
+// @Success      202         {object}  models.RestaurantEdit
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /restaurants/{id}/attributes [put]
+func SetRestaurantAttributes(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+
+    var input models.SetAttributesInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    if restaurant.CreatedByID != c.GetUint("userID") && !middlewares.HasPermission(c, rbac.RestaurantsEdit) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own listings"})
+        return
+    }
+
+    current, err := restaurantFields(db.DB, restaurant)
+    if err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attributes"})
+        return
+    }
+    changes := map[string]string{}
+    for field, value := range attributeFields(input.PriceLevel, input.Dietary, input.Amenities) {
+        if value != current[field] {
+            changes[field] = value
+        }
+    }
+    if len(changes) > 0 && !submitRestaurantChanges(c, restaurant, changes) {
+        return
+    }
+    if err := db.DB.First(&restaurant, restaurant.ID).Error; err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save attributes"})
+        return
+    }
+
+    c.JSON(http.StatusOK, restaurant)
+}
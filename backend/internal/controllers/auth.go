@@ -2,30 +2,29 @@ package controllers
 
 import (
     "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/metrics"
     "github.com/pp00x/foodiebaba/internal/models"
     "github.com/pp00x/foodiebaba/internal/utils"
     "github.com/pp00x/foodiebaba/pkg/logger"
     "net/http"
-    "os"
-    "time"
+    "strconv"
 
-    "github.com/dgrijalva/jwt-go"
     "github.com/gin-gonic/gin"
     "golang.org/x/crypto/bcrypt"
 )
 
 // Register godoc
 // @Summary      Register a new user
-// @Description  Register a new user with username, email, and password
+// @Description  Register a new user with username, email, and password. A verification link is emailed to the user.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
-// @Param        user body     models.User true "User"
+// @Param        user body     models.RegisterInput true "User"
 // @Success      201  {object} map[string]string
 // @Failure      400  {object} map[string]string
 // @Router       /register [post]
 func Register(c *gin.Context) {
-    var input models.User
+    var input models.RegisterInput
     if err := c.ShouldBindJSON(&input); err != nil {
         logger.Log.Error("Invalid input: ", err)
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -62,19 +61,25 @@ func Register(c *gin.Context) {
         return
     }
 
-    c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
+    if err := sendVerificationEmail(user); err != nil {
+        logger.Log.Error("Error sending verification email: ", err)
+    }
+
+    c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully. Please check your email to verify your account"})
 }
 
 // Login godoc
 // @Summary      User login
-// @Description  Authenticate user and return a JWT token
+// @Description  Authenticate user and return a short-lived JWT access token and a refresh token.
+// @Description  Users with two-factor authentication enabled receive an mfa_token to complete the login at /login/2fa instead.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        credentials body     map[string]string true "Email and Password"
-// @Success      200  {object} map[string]string
+// @Success      200  {object} map[string]interface{}
 // @Failure      400  {object} map[string]string
 // @Failure      401  {object} map[string]string
+// @Failure      429  {object} map[string]string
 // @Router       /login [post]
 func Login(c *gin.Context) {
     var input struct {
@@ -87,26 +92,86 @@ func Login(c *gin.Context) {
         return
     }
 
+    accountKey, ipKey := accountThrottleKey(input.Email), ipThrottleKey(c.ClientIP())
+    retryAfter := loginRetryAfter(accountKey, accountThrottlePolicy())
+    if wait := loginRetryAfter(ipKey, ipThrottlePolicy()); wait > retryAfter {
+        retryAfter = wait
+    }
+    if retryAfter > 0 {
+        metrics.LoginFailures.WithLabelValues("throttled").Inc()
+        c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts. Try again later"})
+        return
+    }
+
+    // Unknown emails and wrong passwords take the same time and log the same message
     var user models.User
-    if err := db.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-        logger.Log.Warnf("User not found: %s", input.Email)
+    err := db.DB.Where("email = ?", input.Email).First(&user).Error
+    if err != nil {
+        compareDummyPassword(input.Password)
+    } else {
+        err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
+    }
+    if err != nil {
+        logger.Log.Warnf("Failed login attempt for %s from %s", input.Email, c.ClientIP())
+        metrics.LoginFailures.WithLabelValues("invalid_credentials").Inc()
+        recordLoginFailure(accountKey, accountThrottlePolicy())
+        recordLoginFailure(ipKey, ipThrottlePolicy())
         c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
         return
     }
 
-    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-        logger.Log.Warnf("Invalid password for user: %s", input.Email)
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+    completeLogin(c, user)
+}
+
+// completeLogin responds with a session for an authenticated user, or with an MFA
+// challenge when the user has two-factor authentication enabled
+func completeLogin(c *gin.Context, user models.User) {
+    if rejectInactiveAccount(c, user) {
         return
     }
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-        "user_id": user.ID,
-        "role":    user.Role,
-        "exp":     time.Now().Add(time.Hour * 72).Unix(),
-    })
-    tokenString, _ := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+    // With two-factor authentication enabled the first factor only earns a challenge token
+    if user.TOTPEnabled {
+        mfaToken, err := utils.GenerateMFAToken(user.ID)
+        if err != nil {
+            logger.Log.Error("Error creating MFA challenge: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+            return
+        }
+        c.JSON(http.StatusOK, gin.H{
+            "mfa_required": true,
+            "mfa_token":    mfaToken,
+            "expires_in":   int(utils.MFAChallengeTTL().Seconds()),
+        })
+        return
+    }
+
+    // Failures are only forgotten once the whole login succeeded
+    if err := resetLoginThrottle(accountThrottleKey(user.Email)); err != nil {
+        logger.Log.Error("Error resetting login throttle: ", err)
+    }
+
+    session, err := issueSession(user, false)
+    if err != nil {
+        logger.Log.Error("Error creating session: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
 
     logger.Log.Infof("User logged in: %s", user.Email)
-    c.JSON(http.StatusOK, gin.H{"token": tokenString})
-}
\ No newline at end of file
+    c.JSON(http.StatusOK, session)
+}
+
+// rejectInactiveAccount responds with 403 and returns true when the user is suspended or banned
+func rejectInactiveAccount(c *gin.Context, user models.User) bool {
+    switch user.AccountStatus() {
+    case models.AccountBanned:
+        c.JSON(http.StatusForbidden, gin.H{"error": "This account has been banned", "code": "account_banned"})
+        return true
+    case models.AccountSuspended:
+        c.JSON(http.StatusForbidden, gin.H{"error": "This account is suspended", "code": "account_suspended", "until": user.SuspendedUntil})
+        return true
+    }
+    return false
+}
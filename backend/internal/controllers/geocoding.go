@@ -0,0 +1,177 @@
+package controllers
+
+import (
+    "context"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/geocoding"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+)
+
+// geocodeRestaurant fills in the coordinates and address components of the restaurant
+// from its address, keeping coordinates that were entered by hand. A failed lookup does
+// not block the change but flags the listing for moderators. It reports whether a
+// geocoder is configured.
+func geocodeRestaurant(restaurant *models.Restaurant) bool {
+    if geocoding.Default == nil {
+        return false
+    }
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    result, err := geocoding.Default.Geocode(ctx, restaurant.Address)
+    if err != nil {
+        logger.Log.Warnf("Geocoding %q failed: %v", restaurant.Address, err)
+        restaurant.GeocodingFailed = true
+        return true
+    }
+    restaurant.GeocodingFailed = false
+    restaurant.Street = result.Street
+    restaurant.City = result.City
+    restaurant.PostalCode = result.PostalCode
+    restaurant.Country = result.Country
+    if restaurant.Latitude == nil || restaurant.Longitude == nil {
+        restaurant.Latitude = &result.Latitude
+        restaurant.Longitude = &result.Longitude
+    }
+    return true
+}
+
+// geocodedColumns returns the columns to update when a restaurant's address changes.
+// Geocoding calls an external service, so call it before opening a transaction. A failed
+// lookup only flags the listing and keeps its current location.
+func geocodedColumns(address string) map[string]interface{} {
+    restaurant := models.Restaurant{Address: address}
+    if !geocodeRestaurant(&restaurant) {
+        return nil
+    }
+    if restaurant.GeocodingFailed {
+        return map[string]interface{}{"geocoding_failed": true}
+    }
+    return map[string]interface{}{
+        "latitude":         restaurant.Latitude,
+        "longitude":        restaurant.Longitude,
+        "street":           restaurant.Street,
+        "city":             restaurant.City,
+        "postal_code":      restaurant.PostalCode,
+        "country":          restaurant.Country,
+        "geocoding_failed": false,
+    }
+}
+
+// locateChanges geocodes the address the changes would give the restaurant, returning
+// nothing when they leave its address as it is
+func locateChanges(restaurant models.Restaurant, changes map[string]string) map[string]interface{} {
+    address, ok := changes["address"]
+    if !ok || address == restaurant.Address {
+        return nil
+    }
+    return geocodedColumns(address)
+}
+
+// GetFlaggedRestaurants godoc
+// @Summary      Get restaurants with unlocated addresses
+// @Description  Moderators can get the restaurants whose address could not be geocoded
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   models.Restaurant
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/flagged [get]
+func GetFlaggedRestaurants(c *gin.Context) {
+    restaurants := []models.Restaurant{}
+    if err := db.DB.Where("geocoding_failed = ?", true).Order("created_at").Find(&restaurants).Error; err != nil {
+        logger.Log.Error("Error fetching flagged restaurants: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flagged restaurants"})
+        return
+    }
+    c.JSON(http.StatusOK, restaurants)
+}
+
+// SetRestaurantLocation godoc
+// @Summary      Set a restaurant's location
+// @Description  Editors and moderators can enter the coordinates and address components of a restaurant by hand, which clears its geocoding flag. Changes to approved listings by users without the restaurants.approve permission are queued for moderation.
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id        path      int                     true  "Restaurant ID"
+// @Param        location  body      models.SetLocationInput true  "Location"
+// @Success      200       {object}  models.Restaurant
+// @Success      202       {object}  models.RestaurantEdit
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /admin/restaurants/{id}/location [put]
+func SetRestaurantLocation(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+
+    var input models.SetLocationInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    current, err := restaurantFields(db.DB, restaurant)
+    if err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set location"})
+        return
+    }
+    changes := map[string]string{}
+    for field, value := range map[string]string{
+        "latitude":    coordinateField(input.Latitude),
+        "longitude":   coordinateField(input.Longitude),
+        "street":      strings.TrimSpace(input.Street),
+        "city":        strings.TrimSpace(input.City),
+        "postal_code": strings.TrimSpace(input.PostalCode),
+        "country":     strings.ToUpper(input.Country),
+    } {
+        if value != current[field] {
+            changes[field] = value
+        }
+    }
+    // confirming the current location only clears the flag
+    if len(changes) == 0 {
+        if err := db.DB.Model(&restaurant).Update("geocoding_failed", false).Error; err != nil {
+            logger.Log.Error("Error clearing geocoding flag: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set location"})
+            return
+        }
+    } else if !submitRestaurantChanges(c, restaurant, changes) {
+        return
+    }
+    if err := db.DB.First(&restaurant, restaurant.ID).Error; err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set location"})
+        return
+    }
+
+    logger.Log.Infof("User %d set the location of restaurant %d", c.GetUint("userID"), restaurant.ID)
+    c.JSON(http.StatusOK, restaurant)
+}
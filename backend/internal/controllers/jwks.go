@@ -0,0 +1,20 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/keys"
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+)
+
+// JWKS godoc
+// @Summary      JSON Web Key Set
+// @Description  Public keys that verify the access tokens issued by this server, identified by kid
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object} jwk.Set
+// @Router       /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+    c.Header("Cache-Control", "public, max-age=300")
+    c.JSON(http.StatusOK, keys.JWKS())
+}
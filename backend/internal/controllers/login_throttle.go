@@ -0,0 +1,131 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/metrics"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "strings"
+    "sync"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+    "gorm.io/gorm/clause"
+)
+
+const maxLoginDelay = time.Minute
+
+// throttlePolicy describes when failed logins start being delayed and when they lock out
+type throttlePolicy struct {
+    scope      string
+    delayAfter int           // failures before each attempt must wait, doubling every time
+    lockAfter  int           // failures that trigger a lockout
+    lockout    time.Duration // first lockout; repeated lockouts double up to a day
+    window     time.Duration // failures older than this are forgotten
+}
+
+func accountThrottlePolicy() throttlePolicy {
+    return throttlePolicy{
+        scope:      "account",
+        delayAfter: configs.GetInt("LOGIN_ACCOUNT_DELAY_AFTER", 3),
+        lockAfter:  configs.GetInt("LOGIN_ACCOUNT_LOCK_AFTER", 10),
+        lockout:    configs.GetDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+        window:     configs.GetDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+    }
+}
+
+func ipThrottlePolicy() throttlePolicy {
+    return throttlePolicy{
+        scope:      "ip",
+        delayAfter: configs.GetInt("LOGIN_IP_DELAY_AFTER", 20),
+        lockAfter:  configs.GetInt("LOGIN_IP_LOCK_AFTER", 50),
+        lockout:    configs.GetDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+        window:     configs.GetDuration("LOGIN_FAILURE_WINDOW", time.Hour),
+    }
+}
+
+func accountThrottleKey(email string) string {
+    return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+    return "ip:" + ip
+}
+
+var (
+    dummyHashOnce sync.Once
+    dummyHash     []byte
+)
+
+// compareDummyPassword spends the same time as a real password check so responses
+// for unknown emails cannot be told apart by timing
+func compareDummyPassword(password string) {
+    dummyHashOnce.Do(func() {
+        dummyHash, _ = bcrypt.GenerateFromPassword([]byte("foodiebaba-dummy-password"), 14)
+    })
+    bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// loginRetryAfter returns how long the key must wait before it may try to log in again
+func loginRetryAfter(key string, policy throttlePolicy) time.Duration {
+    var throttle models.LoginThrottle
+    if err := db.DB.Where("key = ?", key).First(&throttle).Error; err != nil {
+        return 0
+    }
+    now := time.Now()
+    if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+        return throttle.LockedUntil.Sub(now)
+    }
+    if now.Sub(throttle.LastFailureAt) > policy.window || throttle.Failures < policy.delayAfter {
+        return 0
+    }
+    delay := time.Second << uint(throttle.Failures-policy.delayAfter)
+    if delay > maxLoginDelay || delay <= 0 {
+        delay = maxLoginDelay
+    }
+    return throttle.LastFailureAt.Add(delay).Sub(now)
+}
+
+// recordLoginFailure counts a failed attempt and locks the key once it reaches the policy limit
+func recordLoginFailure(key string, policy throttlePolicy) {
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+            return err
+        }
+        var throttle models.LoginThrottle
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&throttle).Error; err != nil {
+            return err
+        }
+
+        now := time.Now()
+        if now.Sub(throttle.LastFailureAt) > policy.window {
+            throttle.Failures = 0
+        }
+        throttle.Failures++
+        throttle.LastFailureAt = now
+
+        if throttle.Failures >= policy.lockAfter {
+            lockout := policy.lockout << uint(throttle.Lockouts)
+            if lockout > 24*time.Hour || lockout <= 0 {
+                lockout = 24 * time.Hour
+            }
+            lockedUntil := now.Add(lockout)
+            throttle.LockedUntil = &lockedUntil
+            throttle.Lockouts++
+            throttle.Failures = 0
+            metrics.LoginLockouts.WithLabelValues(policy.scope).Inc()
+            logger.Log.Warnf("Login locked for %s until %s", key, lockedUntil.Format(time.RFC3339))
+        }
+        return tx.Save(&throttle).Error
+    })
+    if err != nil {
+        logger.Log.Error("Error recording failed login: ", err)
+    }
+}
+
+// resetLoginThrottle forgets the failures of a key after a successful login or an admin unlock
+func resetLoginThrottle(key string) error {
+    return db.DB.Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
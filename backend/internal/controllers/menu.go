@@ -0,0 +1,514 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/accounts"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/middlewares"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "os"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// byPosition orders menus, sections and items the way they are shown
+func byPosition(tx *gorm.DB) *gorm.DB {
+    return tx.Order("position, id")
+}
+
+// canManageMenu reports whether the user may change the restaurant's menus: its creator
+// and users with the restaurants.edit permission can. It responds with an error otherwise.
+func canManageMenu(c *gin.Context, restaurantID uint) bool {
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, restaurantID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return false
+    }
+    if restaurant.CreatedByID != c.GetUint("userID") && !middlewares.HasPermission(c, rbac.RestaurantsEdit) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit the menus of your own listings"})
+        return false
+    }
+    return true
+}
+
+// findManagedMenu loads the menu in the id parameter if the user may change it
+func findManagedMenu(c *gin.Context) (models.Menu, bool) {
+    var menu models.Menu
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid menu ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid menu ID"})
+        return menu, false
+    }
+    if err := db.DB.First(&menu, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Menu not found"})
+        return menu, false
+    }
+    return menu, canManageMenu(c, menu.RestaurantID)
+}
+
+// findManagedSection loads the menu section in the id parameter with its menu if the user may change it
+func findManagedSection(c *gin.Context) (models.MenuSection, models.Menu, bool) {
+    var section models.MenuSection
+    var menu models.Menu
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid section ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+        return section, menu, false
+    }
+    if err := db.DB.First(&section, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Menu section not found"})
+        return section, menu, false
+    }
+    if err := db.DB.First(&menu, section.MenuID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Menu not found"})
+        return section, menu, false
+    }
+    return section, menu, canManageMenu(c, menu.RestaurantID)
+}
+
+// findManagedItem loads the menu item in the id parameter if the user may change it
+func findManagedItem(c *gin.Context) (models.MenuItem, bool) {
+    var item models.MenuItem
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid item ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+        return item, false
+    }
+    if err := db.DB.First(&item, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Menu item not found"})
+        return item, false
+    }
+    return item, canManageMenu(c, item.RestaurantID)
+}
+
+// bindValid binds the JSON body into input and validates it, responding with an error when either fails
+func bindValid(c *gin.Context, input interface{}) bool {
+    if err := c.ShouldBindJSON(input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return false
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return false
+    }
+    return true
+}
+
+// GetRestaurantMenu godoc
+// @Summary      Get a restaurant's menu
+// @Description  Get a restaurant's menus with their sections and items, in display order
+// @Tags         Menus
+// @Produce      json
+// @Param        id   path      int  true  "Restaurant ID"
+// @Success      200  {array}   models.Menu
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /restaurants/{id}/menu [get]
+func GetRestaurantMenu(c *gin.Context) {
+    restaurant, ok := findVisibleRestaurant(c)
+    if !ok {
+        return
+    }
+
+    menus := []models.Menu{}
+    err := db.DB.Preload("Sections", byPosition).Preload("Sections.Items", byPosition).
+        Where("restaurant_id = ?", restaurant.ID).Scopes(byPosition).Find(&menus).Error
+    if err != nil {
+        logger.Log.Error("Error fetching menu: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch menu"})
+        return
+    }
+    c.JSON(http.StatusOK, menus)
+}
+
+// CreateMenu godoc
+// @Summary      Add a menu
+// @Description  The creator of a listing and users with the restaurants.edit permission can add menus to it
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int               true  "Restaurant ID"
+// @Param        menu  body      models.MenuInput  true  "Menu"
+// @Success      201   {object}  models.Menu
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /restaurants/{id}/menus [post]
+func CreateMenu(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+    var input models.MenuInput
+    if !bindValid(c, &input) || !canManageMenu(c, uint(id)) {
+        return
+    }
+
+    menu := models.Menu{
+        RestaurantID: uint(id),
+        Name:         strings.TrimSpace(input.Name),
+        Description:  input.Description,
+        Position:     input.Position,
+        Sections:     []models.MenuSection{},
+    }
+    if err := db.DB.Create(&menu).Error; err != nil {
+        logger.Log.Error("Error creating menu: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create menu"})
+        return
+    }
+    logger.Log.Infof("User %d added menu %d to restaurant %d", c.GetUint("userID"), menu.ID, id)
+    c.JSON(http.StatusCreated, menu)
+}
+
+// UpdateMenu godoc
+// @Summary      Update a menu
+// @Description  Replace a menu's name, description and position
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int               true  "Menu ID"
+// @Param        menu  body      models.MenuInput  true  "Menu"
+// @Success      200   {object}  models.Menu
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /menus/{id} [put]
+func UpdateMenu(c *gin.Context) {
+    var input models.MenuInput
+    if !bindValid(c, &input) {
+        return
+    }
+    menu, ok := findManagedMenu(c)
+    if !ok {
+        return
+    }
+
+    menu.Name, menu.Description, menu.Position = strings.TrimSpace(input.Name), input.Description, input.Position
+    if err := db.DB.Save(&menu).Error; err != nil {
+        logger.Log.Error("Error updating menu: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update menu"})
+        return
+    }
+    c.JSON(http.StatusOK, menu)
+}
+
+// DeleteMenu godoc
+// @Summary      Delete a menu
+// @Description  Delete a menu with its sections and items
+// @Tags         Menus
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Menu ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /menus/{id} [delete]
+func DeleteMenu(c *gin.Context) {
+    menu, ok := findManagedMenu(c)
+    if !ok {
+        return
+    }
+
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        sections := tx.Model(&models.MenuSection{}).Select("id").Where("menu_id = ?", menu.ID)
+        if err := tx.Where("section_id IN (?)", sections).Delete(&models.MenuItem{}).Error; err != nil {
+            return err
+        }
+        if err := tx.Where("menu_id = ?", menu.ID).Delete(&models.MenuSection{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&menu).Error
+    })
+    if err != nil {
+        logger.Log.Error("Error deleting menu: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete menu"})
+        return
+    }
+    logger.Log.Infof("User %d deleted menu %d of restaurant %d", c.GetUint("userID"), menu.ID, menu.RestaurantID)
+    c.JSON(http.StatusOK, gin.H{"message": "Menu deleted"})
+}
+
+// CreateMenuSection godoc
+// @Summary      Add a menu section
+// @Description  Add a section such as "Starters" to a menu
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "Menu ID"
+// @Param        section  body      models.MenuSectionInput  true  "Section"
+// @Success      201      {object}  models.MenuSection
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /menus/{id}/sections [post]
+func CreateMenuSection(c *gin.Context) {
+    var input models.MenuSectionInput
+    if !bindValid(c, &input) {
+        return
+    }
+    menu, ok := findManagedMenu(c)
+    if !ok {
+        return
+    }
+
+    section := models.MenuSection{
+        MenuID:      menu.ID,
+        Name:        strings.TrimSpace(input.Name),
+        Description: input.Description,
+        Position:    input.Position,
+        Items:       []models.MenuItem{},
+    }
+    if err := db.DB.Create(&section).Error; err != nil {
+        logger.Log.Error("Error creating menu section: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create menu section"})
+        return
+    }
+    c.JSON(http.StatusCreated, section)
+}
+
+// UpdateMenuSection godoc
+// @Summary      Update a menu section
+// @Description  Replace a section's name, description and position
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "Section ID"
+// @Param        section  body      models.MenuSectionInput  true  "Section"
+// @Success      200      {object}  models.MenuSection
+// @Failure      400      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /menu-sections/{id} [put]
+func UpdateMenuSection(c *gin.Context) {
+    var input models.MenuSectionInput
+    if !bindValid(c, &input) {
+        return
+    }
+    section, _, ok := findManagedSection(c)
+    if !ok {
+        return
+    }
+
+    section.Name, section.Description, section.Position = strings.TrimSpace(input.Name), input.Description, input.Position
+    if err := db.DB.Save(&section).Error; err != nil {
+        logger.Log.Error("Error updating menu section: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update menu section"})
+        return
+    }
+    c.JSON(http.StatusOK, section)
+}
+
+// DeleteMenuSection godoc
+// @Summary      Delete a menu section
+// @Description  Delete a menu section with its items
+// @Tags         Menus
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Section ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /menu-sections/{id} [delete]
+func DeleteMenuSection(c *gin.Context) {
+    section, _, ok := findManagedSection(c)
+    if !ok {
+        return
+    }
+
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("section_id = ?", section.ID).Delete(&models.MenuItem{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&section).Error
+    })
+    if err != nil {
+        logger.Log.Error("Error deleting menu section: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete menu section"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Menu section deleted"})
+}
+
+// CreateMenuItem godoc
+// @Summary      Add a menu item
+// @Description  Add a dish or drink with its price to a menu section
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                   true  "Section ID"
+// @Param        item  body      models.MenuItemInput  true  "Item"
+// @Success      201   {object}  models.MenuItem
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /menu-sections/{id}/items [post]
+func CreateMenuItem(c *gin.Context) {
+    var input models.MenuItemInput
+    if !bindValid(c, &input) {
+        return
+    }
+    section, menu, ok := findManagedSection(c)
+    if !ok {
+        return
+    }
+
+    item := models.MenuItem{
+        SectionID:    section.ID,
+        RestaurantID: menu.RestaurantID,
+        Name:         strings.TrimSpace(input.Name),
+        Description:  input.Description,
+        Price:        *input.Price,
+        Currency:     input.Currency,
+        Dietary:      input.Dietary,
+        Position:     input.Position,
+    }
+    if err := db.DB.Create(&item).Error; err != nil {
+        logger.Log.Error("Error creating menu item: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create menu item"})
+        return
+    }
+    c.JSON(http.StatusCreated, item)
+}
+
+// UpdateMenuItem godoc
+// @Summary      Update a menu item
+// @Description  Replace an item's name, description, price, currency, dietary flags and position. The photo is kept.
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                   true  "Item ID"
+// @Param        item  body      models.MenuItemInput  true  "Item"
+// @Success      200   {object}  models.MenuItem
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /menu-items/{id} [put]
+func UpdateMenuItem(c *gin.Context) {
+    var input models.MenuItemInput
+    if !bindValid(c, &input) {
+        return
+    }
+    item, ok := findManagedItem(c)
+    if !ok {
+        return
+    }
+
+    item.Name = strings.TrimSpace(input.Name)
+    item.Description = input.Description
+    item.Price = *input.Price
+    item.Currency = input.Currency
+    item.Dietary = input.Dietary
+    item.Position = input.Position
+    if err := db.DB.Save(&item).Error; err != nil {
+        logger.Log.Error("Error updating menu item: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update menu item"})
+        return
+    }
+    c.JSON(http.StatusOK, item)
+}
+
+// DeleteMenuItem godoc
+// @Summary      Delete a menu item
+// @Description  Remove a dish or drink from its menu
+// @Tags         Menus
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Item ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /menu-items/{id} [delete]
+func DeleteMenuItem(c *gin.Context) {
+    item, ok := findManagedItem(c)
+    if !ok {
+        return
+    }
+    if err := db.DB.Delete(&item).Error; err != nil {
+        logger.Log.Error("Error deleting menu item: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete menu item"})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Menu item deleted"})
+}
+
+// UploadMenuItemPhoto godoc
+// @Summary      Upload a menu item photo
+// @Description  Replace the photo of a dish or drink
+// @Tags         Menus
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id     path      int   true  "Item ID"
+// @Param        photo  formData  file  true  "Photo"
+// @Success      200    {object}  models.MenuItem
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /menu-items/{id}/photo [put]
+func UploadMenuItemPhoto(c *gin.Context) {
+    item, ok := findManagedItem(c)
+    if !ok {
+        return
+    }
+    file, err := c.FormFile("photo")
+    if err != nil {
+        logger.Log.Error("Error retrieving form data: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !strings.HasPrefix(file.Header.Get("Content-Type"), "image/") {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Photo must be an image"})
+        return
+    }
+
+    url, err := utils.UploadFile(file)
+    if err != nil {
+        logger.Log.Error("Error uploading file: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    previous := item.PhotoURL
+    if err := db.DB.Model(&item).Update("photo_url", url).Error; err != nil {
+        logger.Log.Error("Error saving menu item photo: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save photo"})
+        return
+    }
+    if path, ok := accounts.UploadPath(previous); ok {
+        if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+            logger.Log.Error("Error removing previous menu item photo: ", err)
+        }
+    }
+    c.JSON(http.StatusOK, item)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+    "errors"
     "github.com/pp00x/foodiebaba/internal/db"
     "github.com/pp00x/foodiebaba/internal/models"
     "github.com/pp00x/foodiebaba/pkg/logger"
@@ -8,11 +9,12 @@ import (
     "strconv"
 
     "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
 )
 
 // GetPendingRestaurants godoc
 // @Summary      Get pending restaurants
-// @Description  Admins can get a list of restaurants pending approval
+// @Description  Moderators can get a list of restaurants pending approval
 // @Tags         Moderation
 // @Security     BearerAuth
 // @Produce      json
@@ -31,11 +33,12 @@ func GetPendingRestaurants(c *gin.Context) {
 
 // ApproveRestaurant godoc
 // @Summary      Approve a restaurant
-// @Description  Admins can approve a pending restaurant
+// @Description  Moderators can approve a pending restaurant
 // @Tags         Moderation
 // @Security     BearerAuth
 // @Param        id   path      int  true  "Restaurant ID"
 // @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /admin/restaurants/{id}/approve [put]
 func ApproveRestaurant(c *gin.Context) {
@@ -45,7 +48,21 @@ func ApproveRestaurant(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
         return
     }
-    if err := db.DB.Model(&models.Restaurant{}).Where("id = ?", id).Update("status", "approved").Error; err != nil {
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        result := tx.Model(&models.Restaurant{}).Where("id = ?", id).Update("status", "approved")
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return gorm.ErrRecordNotFound
+        }
+        return recordRevision(tx, uint(id), c.GetUint("userID"), models.RevisionApproved, "")
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    if err != nil {
         logger.Log.Error("Error approving restaurant: ", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve restaurant"})
         return
@@ -55,11 +72,12 @@ func ApproveRestaurant(c *gin.Context) {
 
 // RejectRestaurant godoc
 // @Summary      Reject a restaurant
-// @Description  Admins can reject a pending restaurant
+// @Description  Moderators can reject a pending restaurant
 // @Tags         Moderation
 // @Security     BearerAuth
 // @Param        id   path      int  true  "Restaurant ID"
 // @Success      200  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
 // @Router       /admin/restaurants/{id}/reject [put]
 func RejectRestaurant(c *gin.Context) {
@@ -69,10 +87,56 @@ func RejectRestaurant(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
         return
     }
-    if err := db.DB.Model(&models.Restaurant{}).Where("id = ?", id).Update("status", "rejected").Error; err != nil {
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        result := tx.Model(&models.Restaurant{}).Where("id = ?", id).Update("status", "rejected")
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return gorm.ErrRecordNotFound
+        }
+        return recordRevision(tx, uint(id), c.GetUint("userID"), models.RevisionRejected, "")
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    if err != nil {
         logger.Log.Error("Error rejecting restaurant: ", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject restaurant"})
         return
     }
     c.JSON(http.StatusOK, gin.H{"message": "Restaurant rejected"})
-}
\ No newline at end of file
+}
+
+// DeleteReview godoc
+// @Summary      Delete a review
+// @Description  Moderators can remove a review
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Review ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/reviews/{id} [delete]
+func DeleteReview(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid review ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+        return
+    }
+    result := db.DB.Delete(&models.Review{}, id)
+    if result.Error != nil {
+        logger.Log.Error("Error deleting review: ", result.Error)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete review"})
+        return
+    }
+    if result.RowsAffected == 0 {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+        return
+    }
+    logger.Log.Infof("Review %d deleted by user %d", id, c.GetUint("userID"))
+    c.JSON(http.StatusOK, gin.H{"message": "Review deleted"})
+}
@@ -0,0 +1,273 @@
+package controllers
+
+import (
+    "crypto/subtle"
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/oidc"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+// oauthStateCookie binds a login's state to the browser that started it, so a state
+// obtained by someone else cannot be completed in a victim's browser
+const oauthStateCookie = "oidc_state"
+
+var (
+    errUnverifiedEmailConflict = errors.New("email belongs to an existing account but is not verified by the provider")
+    usernameUnsafeChars        = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+)
+
+// OIDCLogin godoc
+// @Summary      Start an OpenID Connect login
+// @Description  Redirect to the identity provider using the authorization code flow with PKCE
+// @Tags         Auth
+// @Param        provider path string true "Provider name from OIDC_PROVIDERS"
+// @Success      302
+// @Failure      404  {object} map[string]string
+// @Router       /auth/oidc/{provider}/login [get]
+func OIDCLogin(c *gin.Context) {
+    provider, ok := oidc.Providers[c.Param("provider")]
+    if !ok {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+        return
+    }
+
+    state, err := utils.GenerateRandomToken(32)
+    if err != nil {
+        logger.Log.Error("Error generating OAuth state: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    nonce, err := utils.GenerateRandomToken(32)
+    if err != nil {
+        logger.Log.Error("Error generating OAuth nonce: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    verifier, err := utils.GenerateRandomToken(48)
+    if err != nil {
+        logger.Log.Error("Error generating PKCE verifier: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+    if err != nil {
+        logger.Log.Error("Error building authorization URL: ", err)
+        c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+        return
+    }
+
+    record := models.OAuthState{
+        StateHash:    utils.HashToken(state),
+        Provider:     provider.Name,
+        Nonce:        nonce,
+        CodeVerifier: verifier,
+        ExpiresAt:    time.Now().Add(oauthStateTTL),
+    }
+    if err := db.DB.Create(&record).Error; err != nil {
+        logger.Log.Error("Error saving OAuth state: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    // Opportunistically clean up abandoned logins
+    db.DB.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+
+    // Lax so the cookie is sent on the top-level redirect back from the provider
+    c.SetSameSite(http.SameSiteLaxMode)
+    c.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "/auth/oidc", "", configs.GetBool("SECURE_COOKIES", true), true)
+    c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback godoc
+// @Summary      Complete an OpenID Connect login
+// @Description  Exchange the authorization code, validate the ID token, link or create the user and return the same tokens as /login
+// @Tags         Auth
+// @Produce      json
+// @Param        provider path  string true "Provider name"
+// @Param        code     query string true "Authorization code"
+// @Param        state    query string true "State"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]string
+// @Failure      401  {object} map[string]string
+// @Failure      409  {object} map[string]string
+// @Router       /auth/oidc/{provider}/callback [get]
+func OIDCCallback(c *gin.Context) {
+    provider, ok := oidc.Providers[c.Param("provider")]
+    if !ok {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Unknown identity provider"})
+        return
+    }
+    if errCode := c.Query("error"); errCode != "" {
+        logger.Log.Warnf("OIDC login failed at %s: %s %s", provider.Name, errCode, c.Query("error_description"))
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Login was cancelled or denied by the identity provider"})
+        return
+    }
+    code, state := c.Query("code"), c.Query("state")
+    if code == "" || state == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+        return
+    }
+    cookie, err := c.Cookie(oauthStateCookie)
+    c.SetSameSite(http.SameSiteLaxMode)
+    c.SetCookie(oauthStateCookie, "", -1, "/auth/oidc", "", configs.GetBool("SECURE_COOKIES", true), true)
+    if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+        logger.Log.Warnf("OIDC callback at %s with a state that was not started in this browser", provider.Name)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+        return
+    }
+
+    // The state is single use: delete it and check we actually removed it
+    var stored models.OAuthState
+    if err := db.DB.Where("state_hash = ? AND provider = ? AND expires_at > ?", utils.HashToken(state), provider.Name, time.Now()).
+        First(&stored).Error; err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+        return
+    }
+    if result := db.DB.Delete(&stored); result.Error != nil || result.RowsAffected == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired login state"})
+        return
+    }
+
+    rawIDToken, err := provider.Exchange(c.Request.Context(), code, stored.CodeVerifier)
+    if err != nil {
+        logger.Log.Error("Error exchanging authorization code: ", err)
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to complete login with the identity provider"})
+        return
+    }
+    claims, err := provider.VerifyIDToken(c.Request.Context(), rawIDToken, stored.Nonce)
+    if err != nil {
+        logger.Log.Error("Error verifying ID token: ", err)
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to complete login with the identity provider"})
+        return
+    }
+
+    user, err := findOrCreateOIDCUser(provider.Name, claims)
+    if errors.Is(err, errUnverifiedEmailConflict) {
+        c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists. Log in with your password to continue"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error linking OIDC identity: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    completeLogin(c, user)
+}
+
+// findOrCreateOIDCUser returns the user linked to the identity, linking an existing
+// account by verified email or creating a new one when there is none
+func findOrCreateOIDCUser(provider string, claims *oidc.Claims) (models.User, error) {
+    var user models.User
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        var identity models.UserIdentity
+        err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+        if err == nil {
+            return tx.First(&user, identity.UserID).Error
+        }
+        if !errors.Is(err, gorm.ErrRecordNotFound) {
+            return err
+        }
+        if claims.Email == "" {
+            return fmt.Errorf("identity provider did not return an email address")
+        }
+
+        err = tx.Where("email = ?", claims.Email).First(&user).Error
+        switch {
+        case err == nil:
+            // Only link to an existing account when the provider vouches for the address
+            if !claims.EmailVerified {
+                return errUnverifiedEmailConflict
+            }
+        case errors.Is(err, gorm.ErrRecordNotFound):
+            user, err = newOIDCUser(tx, claims)
+            if err != nil {
+                return err
+            }
+        default:
+            return err
+        }
+
+        if claims.EmailVerified && !user.EmailVerified {
+            now := time.Now()
+            user.EmailVerified = true
+            user.EmailVerifiedAt = &now
+            if err := tx.Model(&user).Updates(map[string]interface{}{"email_verified": true, "email_verified_at": now}).Error; err != nil {
+                return err
+            }
+        }
+
+        identity = models.UserIdentity{
+            UserID:   user.ID,
+            Provider: provider,
+            Subject:  claims.Subject,
+            Email:    claims.Email,
+        }
+        return tx.Create(&identity).Error
+    })
+    return user, err
+}
+
+// newOIDCUser creates a user with a unique username and a random password they never see
+func newOIDCUser(tx *gorm.DB, claims *oidc.Claims) (models.User, error) {
+    base := claims.PreferredUsername
+    if base == "" {
+        base = strings.Split(claims.Email, "@")[0]
+    }
+    base = usernameUnsafeChars.ReplaceAllString(base, "")
+    if base == "" {
+        base = "user"
+    }
+    if len(base) > 80 {
+        base = base[:80]
+    }
+
+    username := base
+    for i := 2; ; i++ {
+        var count int64
+        if err := tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error; err != nil {
+            return models.User{}, err
+        }
+        if count == 0 {
+            break
+        }
+        username = fmt.Sprintf("%s%d", base, i)
+    }
+
+    password, err := utils.GenerateRandomToken(32)
+    if err != nil {
+        return models.User{}, err
+    }
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return models.User{}, err
+    }
+
+    user := models.User{
+        Username:     username,
+        Email:        claims.Email,
+        Password:     string(hashedPassword),
+        Passwordless: true,
+        Role:         "user",
+    }
+    if err := tx.Create(&user).Error; err != nil {
+        return models.User{}, err
+    }
+    logger.Log.Infof("User registered through OIDC: %s", user.Email)
+    return user, nil
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/hours"
+    "github.com/pp00x/foodiebaba/internal/middlewares"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+)
+
+// SetOpeningHours godoc
+// @Summary      Set opening hours
+// @Description  Replace a restaurant's weekly opening hours and holiday exceptions. Days can have several intervals; an interval that closes at or before it opens runs past midnight. Only the creator of the listing and users with the restaurants.edit permission can do this; changes to approved listings are queued for moderation unless made by a moderator.
+// @Tags         Restaurants
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id     path      int                          true  "Restaurant ID"
+// @Param        hours  body      models.OpeningHoursSchedule  true  "Opening hours"
+// @Success      200    {object}  models.OpeningHoursSchedule
+// @Success      202    {object}  models.RestaurantEdit
+// @Failure      400    {object}  map[string]string
+// @Failure      403    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /restaurants/{id}/hours [put]
+func SetOpeningHours(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+
+    var input models.OpeningHoursSchedule
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    if restaurant.CreatedByID != c.GetUint("userID") && !middlewares.HasPermission(c, rbac.RestaurantsEdit) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own listings"})
+        return
+    }
+
+    valid, err := hours.ValidTimeZone(db.DB, input.TimeZone)
+    if err != nil {
+        logger.Log.Error("Error checking time zone: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save opening hours"})
+        return
+    }
+    if !valid {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown time zone " + strconv.Quote(input.TimeZone)})
+        return
+    }
+    rows, err := hours.Rows(restaurant.ID, input)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    schedule, err := hoursField(input.TimeZone, rows)
+    if err != nil {
+        logger.Log.Error("Error encoding opening hours: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save opening hours"})
+        return
+    }
+    current, err := restaurantFields(db.DB, restaurant)
+    if err != nil {
+        logger.Log.Error("Error fetching opening hours: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save opening hours"})
+        return
+    }
+    if schedule != current["hours"] && !submitRestaurantChanges(c, restaurant, map[string]string{"hours": schedule}) {
+        return
+    }
+
+    c.JSON(http.StatusOK, hours.View(input.TimeZone, rows))
+}
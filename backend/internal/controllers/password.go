@@ -0,0 +1,123 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "github.com/pp00x/foodiebaba/pkg/mailer"
+    "net/http"
+    "net/url"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+type forgotPasswordInput struct {
+    Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordInput struct {
+    Token    string `json:"token" binding:"required"`
+    Password string `json:"password" binding:"required,min=8"`
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a single-use password reset link. The response is the same whether or not the email is registered.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body     forgotPasswordInput true "Email"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Router       /password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+    var input forgotPasswordInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    response := gin.H{"message": "If the email is registered, a password reset link has been sent"}
+
+    var user models.User
+    if err := db.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
+        c.JSON(http.StatusOK, response)
+        return
+    }
+
+    ttl := configs.GetDuration("PASSWORD_RESET_TTL", time.Hour)
+    token, err := createUserToken(db.DB, user.ID, models.TokenPurposePasswordReset, ttl)
+    if err != nil {
+        logger.Log.Error("Error creating password reset token: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    link := frontendURL("/reset-password?token=" + url.QueryEscape(token))
+    err = mailer.Send(mailer.Message{
+        To:      user.Email,
+        Subject: "Reset your FoodieBaba password",
+        Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can only be used once.\n\n%s\n\nIf you did not ask for a password reset you can ignore this email.\n",
+            user.Username, ttl, link),
+    })
+    if err != nil {
+        logger.Log.Error("Error sending password reset email: ", err)
+    }
+
+    c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password using a reset token. Every existing session of the user is revoked.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body     resetPasswordInput true "Reset token and new password"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Router       /password/reset [post]
+func ResetPassword(c *gin.Context) {
+    var input resetPasswordInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), 14)
+    if err != nil {
+        logger.Log.Error("Error hashing password: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        token, err := consumeUserToken(tx, input.Token, models.TokenPurposePasswordReset)
+        if err != nil {
+            return err
+        }
+        if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{"password": string(hashedPassword), "passwordless": false}).Error; err != nil {
+            return err
+        }
+        return revokeUserSessions(tx, token.UserID)
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error resetting password: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+)
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Description  Get the logged in user's profile with their listings and reviews
+// @Tags         Profile
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object}  models.User
+// @Failure      404  {object}  map[string]string
+// @Router       /me [get]
+func GetMe(c *gin.Context) {
+    var user models.User
+    if err := db.DB.Preload("Listings").Preload("Reviews").First(&user, c.GetUint("userID")).Error; err != nil {
+        logger.Log.Error("Error fetching user: ", err)
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe godoc
+// @Summary      Edit the current user's profile
+// @Description  Update display name, bio, home city and dietary preferences. Omitted fields are left unchanged.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        profile body      models.UpdateProfileInput true "Profile"
+// @Success      200     {object}  models.User
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /me [patch]
+func UpdateMe(c *gin.Context) {
+    var input models.UpdateProfileInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+
+    var columns []string
+    if input.DisplayName != nil {
+        user.DisplayName = strings.TrimSpace(*input.DisplayName)
+        columns = append(columns, "display_name")
+    }
+    if input.Bio != nil {
+        user.Bio = strings.TrimSpace(*input.Bio)
+        columns = append(columns, "bio")
+    }
+    if input.HomeCity != nil {
+        user.HomeCity = strings.TrimSpace(*input.HomeCity)
+        columns = append(columns, "home_city")
+    }
+    if input.DietaryPreferences != nil {
+        user.DietaryPreferences = *input.DietaryPreferences
+        columns = append(columns, "dietary_preferences")
+    }
+    if len(columns) > 0 {
+        if err := db.DB.Model(&user).Select(columns).Updates(&user).Error; err != nil {
+            logger.Log.Error("Error updating profile: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+            return
+        }
+    }
+
+    c.JSON(http.StatusOK, user)
+}
+
+// UploadAvatar godoc
+// @Summary      Upload an avatar
+// @Description  Replace the logged in user's avatar image
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        avatar  formData  file  true  "Avatar image"
+// @Success      200     {object}  models.User
+// @Failure      400     {object}  map[string]string
+// @Failure      500     {object}  map[string]string
+// @Router       /me/avatar [put]
+func UploadAvatar(c *gin.Context) {
+    file, err := c.FormFile("avatar")
+    if err != nil {
+        logger.Log.Error("Error retrieving form data: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if !strings.HasPrefix(file.Header.Get("Content-Type"), "image/") {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Avatar must be an image"})
+        return
+    }
+
+    url, err := utils.UploadFile(file)
+    if err != nil {
+        logger.Log.Error("Error uploading file: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if err := db.DB.Model(&user).Update("avatar_url", url).Error; err != nil {
+        logger.Log.Error("Error saving avatar: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save avatar"})
+        return
+    }
+    user.AvatarURL = url
+    c.JSON(http.StatusOK, user)
+}
+
+// ChangePassword godoc
+// @Summary      Change password
+// @Description  Change the logged in user's password. Requires the current password; passwordless accounts set their first password through /password/forgot. Other sessions are logged out and a new session is returned.
+// @Tags         Profile
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body      models.ChangePasswordInput true "Current and new password"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      401  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /me/password [post]
+func ChangePassword(c *gin.Context) {
+    var input models.ChangePasswordInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if user.Passwordless {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Your account has no password yet. Use /password/forgot to set one by email"})
+        return
+    }
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+        logger.Log.Warnf("Invalid current password for user: %d", user.ID)
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+        return
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), 14)
+    if err != nil {
+        logger.Log.Error("Error hashing password: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    if err := db.DB.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+        logger.Log.Error("Error updating password: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+        return
+    }
+    if err := revokeUserSessions(db.DB, user.ID); err != nil {
+        logger.Log.Error("Error revoking sessions: ", err)
+    }
+
+    session, err := issueSession(user, c.GetBool("userMFA"))
+    if err != nil {
+        logger.Log.Error("Error creating session: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    c.JSON(http.StatusOK, session)
+}
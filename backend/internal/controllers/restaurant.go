@@ -1,28 +1,54 @@
 package controllers
 
 import (
+    "fmt"
     "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/geo"
+    "github.com/pp00x/foodiebaba/internal/hours"
+    "github.com/pp00x/foodiebaba/internal/middlewares"
     "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/search"
+    "github.com/pp00x/foodiebaba/internal/taxonomy"
     "github.com/pp00x/foodiebaba/internal/utils"
     "github.com/pp00x/foodiebaba/pkg/logger"
+    "math"
     "net/http"
     "strconv"
+    "strings"
+    "time"
 
     "github.com/gin-gonic/gin"
     "gorm.io/gorm"
 )
 
+// maxSearchRadiusKm bounds location searches so they stay cheap
+const maxSearchRadiusKm = 50.0
+
 // GetRestaurants godoc
 // @Summary      List restaurants
-// @Description  Get a list of approved restaurants with optional pagination, search, and filtering
+// @Description  Get a list of approved restaurants with optional pagination, search, and filtering. When lat and lng are given only restaurants within the radius are returned, nearest first, with their distance in km.
 // @Tags         Restaurants
 // @Accept       json
 // @Produce      json
 // @Param        page     query     int    false  "Page number"
 // @Param        limit    query     int    false  "Page size"
 // @Param        name     query     string false  "Search by name"
-// @Param        category query     string false  "Filter by category"
+// @Param        q        query     string false  "Full-text search over name, category, description and address"
+// @Param        category query     string false  "Filter by the cuisine or tag a category names, like tags, or else by category text"
+// @Param        dish     query     string false  "Only restaurants with a menu item whose name contains this"
+// @Param        tags     query     string false  "Comma-separated tag slugs; a tag also matches the tags nested below it"
+// @Param        match    query     string false  "all (default) to require every tag, any to require one of them"
+// @Param        price    query     string false  "Comma-separated price levels from 1 to 4"
+// @Param        dietary  query     string false  "Comma-separated dietary options that must all be offered (vegetarian, vegan, halal, gluten_free)"
+// @Param        amenities query    string false  "Comma-separated amenities that must all be offered (outdoor_seating, wheelchair_accessible, parking, wifi, delivery, takeaway)"
+// @Param        lat      query     number false  "Latitude to search around"
+// @Param        lng      query     number false  "Longitude to search around"
+// @Param        radius   query     number false  "Search radius in km around lat and lng (default 5, max 50)"
+// @Param        open_now query     bool   false  "Only restaurants open now"
+// @Param        open_at  query     string false  "Only restaurants open at this RFC 3339 time"
 // @Success      200      {array}   models.Restaurant
+// @Failure      400      {object}  map[string]string
 // @Failure      500      {object}  map[string]string
 // @Router       /restaurants [get]
 func GetRestaurants(c *gin.Context) {
@@ -41,15 +67,107 @@ func GetRestaurants(c *gin.Context) {
 
     // Search and filter parameters
     name := c.Query("name")
-    category := c.Query("category")
 
     // Build the query
-    query := db.DB.Preload("Photos").Preload("Reviews").Where("status = ?", "approved")
+    query := db.DB.Preload("Photos").Preload("Reviews").Preload("Tags").Where("status = ?", "approved")
     if name != "" {
         query = query.Where("name ILIKE ?", "%"+name+"%")
     }
-    if category != "" {
-        query = query.Where("category ILIKE ?", "%"+category+"%")
+    if text := c.Query("q"); text != "" {
+        if q, ok := search.Query(text); ok {
+            query = query.Where(search.MatchSQL, q)
+        }
+    }
+    if dish := c.Query("dish"); dish != "" {
+        query = query.Where("EXISTS (SELECT 1 FROM menu_items mi WHERE mi.restaurant_id = restaurants.id AND mi.deleted_at IS NULL AND mi.name ILIKE ?)", "%"+dish+"%")
+    }
+
+    // Tag filter, "all" by default
+    if tags := c.Query("tags"); tags != "" {
+        query, err = tagFilter(query, strings.Split(tags, ","), c.DefaultQuery("match", "all") != "any")
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+    }
+    // A category is looked up in the taxonomy and filters like its tag; one the taxonomy
+    // does not know is matched against the category text as before tags existed
+    if category := c.Query("category"); category != "" {
+        var all []models.Tag
+        if err := db.DB.Find(&all).Error; err != nil {
+            logger.Log.Error("Error fetching tags: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restaurants"})
+            return
+        }
+        if tag, ok := taxonomy.Match(all, category); ok {
+            if query, err = tagFilter(query, []string{tag.Slug}, true); err != nil {
+                c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+                return
+            }
+        } else {
+            query = query.Where("category ILIKE ?", "%"+category+"%")
+        }
+    }
+
+    // Attribute filters
+    if price := c.Query("price"); price != "" {
+        var levels []int
+        for _, value := range strings.Split(price, ",") {
+            level, err := strconv.Atoi(value)
+            if err != nil || level < 1 || level > 4 {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "price must be a comma-separated list of levels from 1 to 4"})
+                return
+            }
+            levels = append(levels, level)
+        }
+        query = query.Where("price_level IN ?", levels)
+    }
+    for param, columns := range map[string]map[string]string{"dietary": models.DietaryColumns, "amenities": models.AmenityColumns} {
+        if values := c.Query(param); values != "" {
+            for _, value := range strings.Split(values, ",") {
+                column, ok := columns[value]
+                if !ok {
+                    c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown %s option %q", param, value)})
+                    return
+                }
+                query = query.Where(column + " = ?", true)
+            }
+        }
+    }
+
+    // Location search
+    if c.Query("lat") != "" || c.Query("lng") != "" {
+        lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+        lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+        if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng must both be valid coordinates"})
+            return
+        }
+        radius, err := strconv.ParseFloat(c.DefaultQuery("radius", "5"), 64)
+        if err != nil || radius <= 0 || radius > maxSearchRadiusKm {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius must be between 0 and %g km", maxSearchRadiusKm)})
+            return
+        }
+
+        box := geo.BoundingBox(lat, lng, radius)
+        query = query.Select("restaurants.*, "+geo.DistanceSQL+" AS distance", lat, lat, lng).
+            Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+        if !box.WrapsLng {
+            query = query.Where("longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+        }
+        query = query.Where(geo.DistanceSQL+" <= ?", lat, lat, lng, radius).Order("distance")
+    }
+
+    // Opening hours filter
+    if openAt := c.Query("open_at"); openAt != "" {
+        t, err := time.Parse(time.RFC3339, openAt)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "open_at must be an RFC 3339 time such as 2024-05-01T19:30:00+05:30"})
+            return
+        }
+        query = query.Where(hours.OpenAtSQL, t)
+    } else if c.Query("open_now") == "true" {
+        query = query.Where(hours.OpenAtSQL, time.Now())
     }
 
     // Execute the query with pagination
@@ -62,6 +180,125 @@ func GetRestaurants(c *gin.Context) {
     c.JSON(http.StatusOK, restaurants)
 }
 
+// findVisibleRestaurant loads the restaurant from the id path parameter, responding with 404
+// when it does not exist or is pending or rejected and the requester is neither its creator nor a moderator
+func findVisibleRestaurant(c *gin.Context) (models.Restaurant, bool) {
+    var restaurant models.Restaurant
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return restaurant, false
+    }
+    if err := db.DB.First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return restaurant, false
+    }
+    if restaurant.Status != "approved" {
+        userID := c.GetUint("userID")
+        if userID == 0 || (userID != restaurant.CreatedByID && !middlewares.HasPermission(c, rbac.RestaurantsApprove)) {
+            c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+            return restaurant, false
+        }
+    }
+    return restaurant, true
+}
+
+// GetRestaurant godoc
+// @Summary      Get a restaurant
+// @Description  Get a restaurant with its average rating, review count, rating histogram, photos, opening hours with whether it is open now, and the first page of reviews. Pending and rejected listings are only visible to their creator and moderators.
+// @Tags         Restaurants
+// @Produce      json
+// @Param        id     path      int  true   "Restaurant ID"
+// @Param        limit  query     int  false  "Number of reviews to include"
+// @Success      200    {object}  models.RestaurantDetail
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /restaurants/{id} [get]
+func GetRestaurant(c *gin.Context) {
+    restaurant, ok := findVisibleRestaurant(c)
+    if !ok {
+        return
+    }
+    _, limit, _ := pagination(c)
+
+    detail := models.RestaurantDetail{
+        ID:              restaurant.ID,
+        CreatedAt:       restaurant.CreatedAt,
+        UpdatedAt:       restaurant.UpdatedAt,
+        Name:            restaurant.Name,
+        Address:         restaurant.Address,
+        Category:        restaurant.Category,
+        Description:     restaurant.Description,
+        Status:          restaurant.Status,
+        CreatedByID:     restaurant.CreatedByID,
+        Latitude:        restaurant.Latitude,
+        Longitude:       restaurant.Longitude,
+        Street:          restaurant.Street,
+        City:            restaurant.City,
+        PostalCode:      restaurant.PostalCode,
+        Country:         restaurant.Country,
+        RatingHistogram: map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+        Photos:          []models.Photo{},
+        Tags:            []models.Tag{},
+        PriceLevel:      restaurant.PriceLevel,
+        Dietary:         restaurant.Dietary,
+        Amenities:       restaurant.Amenities,
+        Reviews:         []models.RestaurantReview{},
+    }
+
+    var histogram []struct {
+        Rating int
+        Count  int64
+    }
+    err := db.DB.Model(&models.Review{}).Select("rating, COUNT(*) AS count").Where("restaurant_id = ?", restaurant.ID).Group("rating").Scan(&histogram).Error
+    if err == nil {
+        err = db.DB.Where("restaurant_id = ?", restaurant.ID).Order("id").Find(&detail.Photos).Error
+    }
+    if err == nil {
+        err = db.DB.Model(&models.Review{}).
+            Select("reviews.id, reviews.created_at, reviews.rating, reviews.comment, reviews.user_id, users.username, users.display_name, users.avatar_url").
+            Joins("LEFT JOIN users ON users.id = reviews.user_id").
+            Where("reviews.restaurant_id = ?", restaurant.ID).
+            Order("reviews.created_at DESC").Limit(limit).
+            Scan(&detail.Reviews).Error
+    }
+    var openingHours []models.OpeningHours
+    if err == nil {
+        err = db.DB.Where("restaurant_id = ?", restaurant.ID).Find(&openingHours).Error
+    }
+    if err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restaurant"})
+        return
+    }
+    if err := db.DB.Model(&restaurant).Association("Tags").Find(&detail.Tags); err != nil {
+        logger.Log.Error("Error fetching restaurant tags: ", err)
+    }
+    if len(openingHours) > 0 {
+        schedule := hours.View(restaurant.TimeZone, openingHours)
+        status := hours.NewSchedule(restaurant.TimeZone, openingHours).Status(time.Now())
+        detail.OpeningHours = &schedule
+        detail.OpenStatus = &status
+    }
+
+    var total int64
+    for _, row := range histogram {
+        detail.RatingHistogram[row.Rating] = row.Count
+        detail.ReviewCount += row.Count
+        total += int64(row.Rating) * row.Count
+    }
+    if detail.ReviewCount > 0 {
+        detail.AverageRating = math.Round(float64(total)/float64(detail.ReviewCount)*100) / 100
+    }
+    if len(detail.Photos) > 0 {
+        detail.CoverPhoto = &detail.Photos[0]
+    }
+
+    c.JSON(http.StatusOK, detail)
+}
+
 // AddRestaurant godoc
 // @Summary      Add a new restaurant
 // @Description  Users can add a new restaurant listing (requires approval)
@@ -91,17 +328,55 @@ func AddRestaurant(c *gin.Context) {
 
     userID := c.GetUint("userID")
 
+    // Tag the listing, falling back to the taxonomy entry its category matches
+    tags, err := resolveTags(input.Tags)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if len(tags) == 0 {
+        if strings.TrimSpace(input.Category) == "" {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "A restaurant needs tags or a category"})
+            return
+        }
+        var all []models.Tag
+        if err := db.DB.Find(&all).Error; err != nil {
+            logger.Log.Error("Error fetching tags: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add restaurant"})
+            return
+        }
+        tag, ok := taxonomy.Match(all, input.Category)
+        if !ok {
+            c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("No cuisine or tag matches the category %q, choose tags instead", input.Category)})
+            return
+        }
+        tags = append(tags, tag)
+    }
+
     // Create a new Restaurant instance with the validated input and additional fields
     restaurant := models.Restaurant{
         Name:        input.Name,
         Address:     input.Address,
-        Category:    input.Category,
+        Category:    primaryCuisine(tags),
         Description: input.Description,
         CreatedByID: userID,
         Status:      "pending",
+        Latitude:    input.Latitude,
+        Longitude:   input.Longitude,
+        Tags:        tags,
+        PriceLevel:  input.PriceLevel,
+        Dietary:     input.Dietary,
+        Amenities:   input.Amenities,
     }
+    geocodeRestaurant(&restaurant)
 
-    if err := db.DB.Create(&restaurant).Error; err != nil {
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Create(&restaurant).Error; err != nil {
+            return err
+        }
+        return recordRevision(tx, restaurant.ID, userID, models.RevisionCreated, "")
+    })
+    if err != nil {
         logger.Log.Error("Error adding restaurant: ", err)
         c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add restaurant"})
         return
@@ -155,6 +430,7 @@ func UploadPhotos(c *gin.Context) {
         photo := models.Photo{
             URL:          url,
             RestaurantID: uint(restaurantID),
+            UploadedByID: c.GetUint("userID"),
         }
         photos = append(photos, photo)
     }
@@ -0,0 +1,468 @@
+package controllers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/hours"
+    "github.com/pp00x/foodiebaba/internal/middlewares"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+var (
+    errEditNotPending = errors.New("edit is not pending")
+    errEditOutdated   = errors.New("listing changed since the edit was submitted")
+)
+
+// restaurantChanges returns the columns the input would change on the restaurant
+func restaurantChanges(restaurant models.Restaurant, input models.UpdateRestaurantInput) (map[string]string, error) {
+    fields := []struct {
+        column   string
+        current  string
+        proposed *string
+    }{
+        {"name", restaurant.Name, input.Name},
+        {"address", restaurant.Address, input.Address},
+        {"description", restaurant.Description, input.Description},
+    }
+    changes := map[string]string{}
+    for _, field := range fields {
+        if field.proposed == nil {
+            continue
+        }
+        value := strings.TrimSpace(*field.proposed)
+        if value == "" {
+            return nil, errors.New(field.column + " cannot be empty")
+        }
+        if value != field.current {
+            changes[field.column] = value
+        }
+    }
+    return changes, nil
+}
+
+// restaurantFields returns the editable fields of the restaurant as edits and revisions
+// store them. Tags and opening hours are read in tx; tags are kept as their sorted slugs
+// separated by commas, opening hours as their JSON schedule, flags as "true" or "false"
+// and unknown numbers as empty strings.
+func restaurantFields(tx *gorm.DB, restaurant models.Restaurant) (map[string]string, error) {
+    var tags []models.Tag
+    if err := tx.Model(&restaurant).Association("Tags").Find(&tags); err != nil {
+        return nil, err
+    }
+    var rows []models.OpeningHours
+    if err := tx.Where("restaurant_id = ?", restaurant.ID).Find(&rows).Error; err != nil {
+        return nil, err
+    }
+    schedule, err := hoursField(restaurant.TimeZone, rows)
+    if err != nil {
+        return nil, err
+    }
+    fields := attributeFields(restaurant.PriceLevel, restaurant.Dietary, restaurant.Amenities)
+    fields["name"] = restaurant.Name
+    fields["address"] = restaurant.Address
+    fields["category"] = restaurant.Category
+    fields["description"] = restaurant.Description
+    fields["latitude"] = coordinateField(restaurant.Latitude)
+    fields["longitude"] = coordinateField(restaurant.Longitude)
+    fields["street"] = restaurant.Street
+    fields["city"] = restaurant.City
+    fields["postal_code"] = restaurant.PostalCode
+    fields["country"] = restaurant.Country
+    fields["tags"] = tagsField(tags)
+    fields["hours"] = schedule
+    return fields, nil
+}
+
+// attributeFields encodes the price level, dietary options and amenities as fields
+func attributeFields(priceLevel *int, dietary models.DietaryOptions, amenities models.Amenities) map[string]string {
+    price := ""
+    if priceLevel != nil {
+        price = strconv.Itoa(*priceLevel)
+    }
+    return map[string]string{
+        "price_level":                   price,
+        "dietary_vegetarian":            strconv.FormatBool(dietary.Vegetarian),
+        "dietary_vegan":                 strconv.FormatBool(dietary.Vegan),
+        "dietary_halal":                 strconv.FormatBool(dietary.Halal),
+        "dietary_gluten_free":           strconv.FormatBool(dietary.GlutenFree),
+        "amenity_outdoor_seating":       strconv.FormatBool(amenities.OutdoorSeating),
+        "amenity_wheelchair_accessible": strconv.FormatBool(amenities.WheelchairAccessible),
+        "amenity_parking":               strconv.FormatBool(amenities.Parking),
+        "amenity_wifi":                  strconv.FormatBool(amenities.WiFi),
+        "amenity_delivery":              strconv.FormatBool(amenities.Delivery),
+        "amenity_takeaway":              strconv.FormatBool(amenities.Takeaway),
+    }
+}
+
+// coordinateField encodes a latitude or longitude as a field value
+func coordinateField(value *float64) string {
+    if value == nil {
+        return ""
+    }
+    return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// tagsField encodes tags as a field value
+func tagsField(tags []models.Tag) string {
+    slugs := make([]string, len(tags))
+    for i, tag := range tags {
+        slugs[i] = tag.Slug
+    }
+    sort.Strings(slugs)
+    return strings.Join(slugs, ",")
+}
+
+// hoursField encodes opening hours as a field value, empty when they are unknown
+func hoursField(timeZone string, rows []models.OpeningHours) (string, error) {
+    if timeZone == "" && len(rows) == 0 {
+        return "", nil
+    }
+    data, err := json.Marshal(hours.View(timeZone, rows))
+    return string(data), err
+}
+
+// applyRestaurantChanges writes changed fields to the restaurant along with the
+// location locateChanges found for them
+func applyRestaurantChanges(tx *gorm.DB, restaurantID uint, changes map[string]string, located map[string]interface{}) error {
+    updates := make(map[string]interface{}, len(changes)+len(located))
+    for column, value := range located {
+        updates[column] = value
+    }
+    for field, value := range changes {
+        switch field {
+        case "category":
+            // the category follows the tags; edits queued before that may still carry it
+        case "tags":
+            tags, err := resolveTags(strings.FieldsFunc(value, func(r rune) bool { return r == ',' }))
+            if err != nil {
+                return err
+            }
+            if err := tx.Model(&models.Restaurant{ID: restaurantID}).Association("Tags").Replace(tags); err != nil {
+                return err
+            }
+            updates["category"] = primaryCuisine(tags)
+        case "hours":
+            var schedule models.OpeningHoursSchedule
+            if value != "" {
+                if err := json.Unmarshal([]byte(value), &schedule); err != nil {
+                    return err
+                }
+            }
+            rows, err := hours.Rows(restaurantID, schedule)
+            if err != nil {
+                return err
+            }
+            if err := tx.Where("restaurant_id = ?", restaurantID).Delete(&models.OpeningHours{}).Error; err != nil {
+                return err
+            }
+            if len(rows) > 0 {
+                if err := tx.Create(&rows).Error; err != nil {
+                    return err
+                }
+            }
+            updates["time_zone"] = schedule.TimeZone
+        case "price_level":
+            updates[field] = nil
+            if value != "" {
+                level, err := strconv.Atoi(value)
+                if err != nil {
+                    return err
+                }
+                updates[field] = level
+            }
+        case "latitude", "longitude":
+            // coordinates are only changed by hand or by rolling back, either way a person checked them
+            updates["geocoding_failed"] = false
+            updates[field] = nil
+            if value != "" {
+                coordinate, err := strconv.ParseFloat(value, 64)
+                if err != nil {
+                    return err
+                }
+                updates[field] = coordinate
+            }
+        default:
+            if strings.HasPrefix(field, "dietary_") || strings.HasPrefix(field, "amenity_") {
+                flag, err := strconv.ParseBool(value)
+                if err != nil {
+                    return err
+                }
+                updates[field] = flag
+                continue
+            }
+            updates[field] = value
+        }
+    }
+    if len(updates) == 0 {
+        return nil
+    }
+    return tx.Model(&models.Restaurant{}).Where("id = ?", restaurantID).Updates(updates).Error
+}
+
+// submitRestaurantChanges applies the changes and records a revision, or queues them for
+// moderation when the listing is approved and the user cannot approve edits. A creator
+// changing a rejected listing resubmits it. It responds itself when the changes are
+// queued or fail, and otherwise reports that they were applied.
+func submitRestaurantChanges(c *gin.Context, restaurant models.Restaurant, changes map[string]string) bool {
+    userID := c.GetUint("userID")
+    canApprove := middlewares.HasPermission(c, rbac.RestaurantsApprove)
+    if restaurant.Status == "approved" && !canApprove {
+        current, err := restaurantFields(db.DB, restaurant)
+        if err != nil {
+            logger.Log.Error("Error fetching restaurant: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update restaurant"})
+            return false
+        }
+        original := make(map[string]string, len(changes))
+        for field := range changes {
+            original[field] = current[field]
+        }
+        edit := models.RestaurantEdit{
+            RestaurantID: restaurant.ID,
+            EditedByID:   userID,
+            Changes:      changes,
+            Original:     original,
+            Status:       models.EditStatusPending,
+        }
+        if err := db.DB.Create(&edit).Error; err != nil {
+            logger.Log.Error("Error saving restaurant edit: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update restaurant"})
+            return false
+        }
+        logger.Log.Infof("User %d submitted edit %d of restaurant %d for review", userID, edit.ID, restaurant.ID)
+        c.JSON(http.StatusAccepted, edit)
+        return false
+    }
+
+    located := locateChanges(restaurant, changes)
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := applyRestaurantChanges(tx, restaurant.ID, changes, located); err != nil {
+            return err
+        }
+        if restaurant.Status == "rejected" && restaurant.CreatedByID == userID && !canApprove {
+            if err := tx.Model(&restaurant).Update("status", "pending").Error; err != nil {
+                return err
+            }
+        }
+        return recordRevision(tx, restaurant.ID, userID, models.RevisionEdited, "")
+    })
+    if err != nil {
+        logger.Log.Error("Error updating restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update restaurant"})
+        return false
+    }
+    logger.Log.Infof("User %d edited restaurant %d", userID, restaurant.ID)
+    return true
+}
+
+// UpdateRestaurant godoc
+// @Summary      Edit a restaurant
+// @Description  The creator of a listing and users with the restaurants.edit permission can change its name, address and description; the category follows the tags. Edits to approved listings are queued for moderation unless made by a moderator; a creator editing a rejected listing resubmits it for approval.
+// @Tags         Restaurants
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                          true  "Restaurant ID"
+// @Param        restaurant  body      models.UpdateRestaurantInput true  "Fields to change"
+// @Success      200         {object}  models.Restaurant
+// @Success      202         {object}  models.RestaurantEdit
+// @Failure      400         {object}  map[string]string
+// @Failure      403         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /restaurants/{id} [patch]
+func UpdateRestaurant(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+
+    var input models.UpdateRestaurantInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    if restaurant.CreatedByID != c.GetUint("userID") && !middlewares.HasPermission(c, rbac.RestaurantsEdit) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own listings"})
+        return
+    }
+
+    changes, err := restaurantChanges(restaurant, input)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if len(changes) == 0 {
+        c.JSON(http.StatusOK, restaurant)
+        return
+    }
+
+    if !submitRestaurantChanges(c, restaurant, changes) {
+        return
+    }
+    if err := db.DB.First(&restaurant, restaurant.ID).Error; err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update restaurant"})
+        return
+    }
+    c.JSON(http.StatusOK, restaurant)
+}
+
+// GetPendingRestaurantEdits godoc
+// @Summary      Get pending restaurant edits
+// @Description  Moderators can get the edits to approved restaurants that are waiting for review
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   models.RestaurantEdit
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/edits [get]
+func GetPendingRestaurantEdits(c *gin.Context) {
+    edits := []models.RestaurantEdit{}
+    if err := db.DB.Where("status = ?", models.EditStatusPending).Order("created_at").Find(&edits).Error; err != nil {
+        logger.Log.Error("Error fetching pending restaurant edits: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pending edits"})
+        return
+    }
+    c.JSON(http.StatusOK, edits)
+}
+
+// ApproveRestaurantEdit godoc
+// @Summary      Approve a restaurant edit
+// @Description  Moderators can apply a pending edit to its restaurant. Edits to fields that changed since the edit was submitted, or to tags that were deleted since, are refused with 409 and should be rejected.
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Edit ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/edits/{id}/approve [put]
+func ApproveRestaurantEdit(c *gin.Context) {
+    reviewRestaurantEdit(c, models.EditStatusApproved)
+}
+
+// RejectRestaurantEdit godoc
+// @Summary      Reject a restaurant edit
+// @Description  Moderators can discard a pending edit
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Edit ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/edits/{id}/reject [put]
+func RejectRestaurantEdit(c *gin.Context) {
+    reviewRestaurantEdit(c, models.EditStatusRejected)
+}
+
+// reviewRestaurantEdit closes a pending edit with the status, applying it when approved
+func reviewRestaurantEdit(c *gin.Context, status string) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid edit ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid edit ID"})
+        return
+    }
+
+    // geocode before the transaction, which looks the edit up again and reports any error
+    var located map[string]interface{}
+    var pending models.RestaurantEdit
+    if status == models.EditStatusApproved && db.DB.First(&pending, id).Error == nil && pending.Status == models.EditStatusPending {
+        var restaurant models.Restaurant
+        if db.DB.First(&restaurant, pending.RestaurantID).Error == nil {
+            located = locateChanges(restaurant, pending.Changes)
+        }
+    }
+
+    reviewerID := c.GetUint("userID")
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        var edit models.RestaurantEdit
+        if err := tx.First(&edit, id).Error; err != nil {
+            return err
+        }
+        if edit.Status != models.EditStatusPending {
+            return errEditNotPending
+        }
+        if status == models.EditStatusApproved {
+            var restaurant models.Restaurant
+            if err := tx.First(&restaurant, edit.RestaurantID).Error; err != nil {
+                return err
+            }
+            // edits queued before Original was recorded cannot be checked
+            current, err := restaurantFields(tx, restaurant)
+            if err != nil {
+                return err
+            }
+            for column, value := range edit.Original {
+                if current[column] != value {
+                    return errEditOutdated
+                }
+            }
+            if err := applyRestaurantChanges(tx, edit.RestaurantID, edit.Changes, located); err != nil {
+                return err
+            }
+            note := fmt.Sprintf("Edit %d approved by user %d", edit.ID, reviewerID)
+            if err := recordRevision(tx, edit.RestaurantID, edit.EditedByID, models.RevisionEdited, note); err != nil {
+                return err
+            }
+        }
+        return tx.Model(&edit).Updates(map[string]interface{}{
+            "status":         status,
+            "reviewed_by_id": reviewerID,
+            "reviewed_at":    time.Now(),
+        }).Error
+    })
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "Edit not found"})
+        return
+    case errors.Is(err, errEditNotPending):
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Edit has already been reviewed"})
+        return
+    case errors.Is(err, errEditOutdated):
+        c.JSON(http.StatusConflict, gin.H{"error": "The listing has changed since this edit was submitted"})
+        return
+    case errors.Is(err, errUnknownTag):
+        c.JSON(http.StatusConflict, gin.H{"error": "The edit refers to a tag that no longer exists"})
+        return
+    case err != nil:
+        logger.Log.Error("Error reviewing restaurant edit: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review edit"})
+        return
+    }
+
+    logger.Log.Infof("User %d marked restaurant edit %d as %s", reviewerID, id, status)
+    c.JSON(http.StatusOK, gin.H{"message": "Edit " + status})
+}
@@ -0,0 +1,188 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "sort"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// recordRevision stores the current state of the restaurant with the fields that
+// changed since its previous revision. Call it in the transaction that made the change.
+func recordRevision(tx *gorm.DB, restaurantID, authorID uint, action, note string) error {
+    var restaurant models.Restaurant
+    if err := tx.First(&restaurant, restaurantID).Error; err != nil {
+        return err
+    }
+    snapshot, err := restaurantFields(tx, restaurant)
+    if err != nil {
+        return err
+    }
+    snapshot["status"] = restaurant.Status
+
+    var previous models.RestaurantRevision
+    err = tx.Where("restaurant_id = ?", restaurantID).Order("id DESC").First(&previous).Error
+    if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+        return err
+    }
+
+    changes := []models.FieldChange{}
+    for field, value := range snapshot {
+        // fields that began to be kept after the previous revision have no known prior value
+        if _, kept := previous.Snapshot[field]; previous.ID != 0 && !kept {
+            continue
+        }
+        if previous.Snapshot[field] != value {
+            changes = append(changes, models.FieldChange{Field: field, From: previous.Snapshot[field], To: value})
+        }
+    }
+    sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+    return tx.Create(&models.RestaurantRevision{
+        RestaurantID: restaurantID,
+        AuthorID:     authorID,
+        Action:       action,
+        Note:         note,
+        Snapshot:     snapshot,
+        Changes:      changes,
+    }).Error
+}
+
+// BackfillRevisions records a baseline revision for restaurants that were listed before
+// revisions were kept, so their first edit is compared against their actual values
+func BackfillRevisions() error {
+    var restaurants []models.Restaurant
+    err := db.DB.Select("id", "created_by_id").
+        Where("NOT EXISTS (SELECT 1 FROM restaurant_revisions r WHERE r.restaurant_id = restaurants.id)").
+        Find(&restaurants).Error
+    if err != nil {
+        return err
+    }
+    for _, restaurant := range restaurants {
+        err := db.DB.Transaction(func(tx *gorm.DB) error {
+            return recordRevision(tx, restaurant.ID, restaurant.CreatedByID, models.RevisionCreated, "Baseline of a listing created before revisions were kept")
+        })
+        if err != nil {
+            return err
+        }
+    }
+    if len(restaurants) > 0 {
+        logger.Log.Infof("Recorded baseline revisions for %d restaurants", len(restaurants))
+    }
+    return nil
+}
+
+// GetRestaurantHistory godoc
+// @Summary      Get a restaurant's history
+// @Description  List the revisions of a restaurant, newest first, with their author and the fields each one changed
+// @Tags         Restaurants
+// @Produce      json
+// @Param        id     path      int  true   "Restaurant ID"
+// @Param        page   query     int  false  "Page number"
+// @Param        limit  query     int  false  "Page size"
+// @Success      200    {array}   models.RestaurantRevision
+// @Failure      400    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /restaurants/{id}/history [get]
+func GetRestaurantHistory(c *gin.Context) {
+    restaurant, ok := findVisibleRestaurant(c)
+    if !ok {
+        return
+    }
+    _, limit, offset := pagination(c)
+
+    revisions := []models.RestaurantRevision{}
+    if err := db.DB.Where("restaurant_id = ?", restaurant.ID).Order("id DESC").Limit(limit).Offset(offset).Find(&revisions).Error; err != nil {
+        logger.Log.Error("Error fetching restaurant history: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+        return
+    }
+    c.JSON(http.StatusOK, revisions)
+}
+
+// RollbackRestaurant godoc
+// @Summary      Roll back a restaurant
+// @Description  Moderators can restore the name, address, description, location, tags, opening hours, price level, dietary options and amenities a restaurant had at a previous revision; its category follows the tags. The rollback is recorded as a new revision; the listing's status is left unchanged.
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Produce      json
+// @Param        id        path      int  true  "Restaurant ID"
+// @Param        revision  path      int  true  "Revision ID"
+// @Success      200       {object}  models.Restaurant
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      500       {object}  map[string]string
+// @Router       /admin/restaurants/{id}/revisions/{revision}/rollback [post]
+func RollbackRestaurant(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+    revisionID, err := strconv.Atoi(c.Param("revision"))
+    if err != nil {
+        logger.Log.Error("Invalid revision ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+        return
+    }
+
+    var revision models.RestaurantRevision
+    if err := db.DB.Where("restaurant_id = ?", id).First(&revision, revisionID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, revision.RestaurantID).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    current, err := restaurantFields(db.DB, restaurant)
+    if err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back restaurant"})
+        return
+    }
+    // only differing fields are written, so an unchanged address is not geocoded again.
+    // Revisions recorded before a field was kept leave it as it is.
+    changes := map[string]string{}
+    for field, value := range revision.Snapshot {
+        if _, editable := current[field]; editable && value != current[field] {
+            changes[field] = value
+        }
+    }
+    located := locateChanges(restaurant, changes)
+
+    userID := c.GetUint("userID")
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        if err := applyRestaurantChanges(tx, revision.RestaurantID, changes, located); err != nil {
+            return err
+        }
+        if err := recordRevision(tx, revision.RestaurantID, userID, models.RevisionRolledBack, fmt.Sprintf("Rolled back to revision %d", revision.ID)); err != nil {
+            return err
+        }
+        return tx.First(&restaurant, revision.RestaurantID).Error
+    })
+    if errors.Is(err, errUnknownTag) {
+        c.JSON(http.StatusConflict, gin.H{"error": "The revision refers to a tag that no longer exists"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error rolling back restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back restaurant"})
+        return
+    }
+
+    logger.Log.Infof("User %d rolled back restaurant %d to revision %d", userID, restaurant.ID, revision.ID)
+    c.JSON(http.StatusOK, restaurant)
+}
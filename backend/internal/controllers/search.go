@@ -0,0 +1,70 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/search"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+)
+
+// Search godoc
+// @Summary      Search restaurants
+// @Description  Full-text search over the name, category, description and address of approved restaurants. Every word must match, as a prefix, so partial words work for typeahead. Results are ordered by relevance weighted by rating and include the name and a description snippet with the matches wrapped in <mark> tags. The total number of matches is returned in the X-Total-Count header.
+// @Tags         Restaurants
+// @Produce      json
+// @Param        q      query     string  true   "Search text"
+// @Param        page   query     int     false  "Page number"
+// @Param        limit  query     int     false  "Page size"
+// @Success      200    {array}   models.SearchResult
+// @Failure      400    {object}  map[string]string
+// @Failure      500    {object}  map[string]string
+// @Router       /search [get]
+func Search(c *gin.Context) {
+    query, ok := search.Query(c.Query("q"))
+    if !ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "q must contain at least one word"})
+        return
+    }
+    _, limit, offset := pagination(c)
+
+    results, total, err := search.Restaurants(query, limit, offset)
+    if err != nil {
+        logger.Log.Error("Error searching restaurants: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search restaurants"})
+        return
+    }
+
+    c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+    c.JSON(http.StatusOK, results)
+}
+
+// maxAutocompleteLimit bounds the number of suggestions a client can ask for
+const maxAutocompleteLimit = 20
+
+// Autocomplete godoc
+// @Summary      Autocomplete the search box
+// @Description  Suggest approved restaurants, and the cuisines and cities that have any, whose name starts with the typed text, each labelled with its type. Cities spelled with different case are suggested once. Suggestions are cached for a short time.
+// @Tags         Restaurants
+// @Produce      json
+// @Param        q      query     string  true   "Typed text"
+// @Param        limit  query     int     false  "Number of suggestions (default 8, max 20)"
+// @Success      200    {array}   models.AutocompleteSuggestion
+// @Failure      500    {object}  map[string]string
+// @Router       /autocomplete [get]
+func Autocomplete(c *gin.Context) {
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "8"))
+    if err != nil || limit < 1 {
+        limit = 8
+    }
+    limit = min(limit, maxAutocompleteLimit)
+
+    suggestions, err := search.Autocomplete(c.Query("q"), limit)
+    if err != nil {
+        logger.Log.Error("Error fetching autocomplete suggestions: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suggestions"})
+        return
+    }
+    c.JSON(http.StatusOK, suggestions)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+    "errors"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+var (
+    errRefreshTokenReused = errors.New("refresh token reuse detected")
+    errAccountInactive    = errors.New("account suspended or banned")
+)
+
+type refreshTokenInput struct {
+    RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// issueSession creates an access token and starts a new refresh token family for the user.
+// mfa records whether the login completed a second factor.
+func issueSession(user models.User, mfa bool) (gin.H, error) {
+    familyID, err := utils.GenerateRandomToken(24)
+    if err != nil {
+        return nil, err
+    }
+    return issueTokens(db.DB, user, familyID, mfa)
+}
+
+// issueTokens signs an access token and stores a new refresh token in the given family
+func issueTokens(tx *gorm.DB, user models.User, familyID string, mfa bool) (gin.H, error) {
+    accessToken, err := utils.GenerateAccessToken(user.ID, user.Role, mfa)
+    if err != nil {
+        return nil, err
+    }
+    refreshToken, err := utils.GenerateRandomToken(32)
+    if err != nil {
+        return nil, err
+    }
+    record := models.RefreshToken{
+        UserID:    user.ID,
+        FamilyID:  familyID,
+        TokenHash: utils.HashToken(refreshToken),
+        ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+        MFA:       mfa,
+    }
+    if err := tx.Create(&record).Error; err != nil {
+        return nil, err
+    }
+    return gin.H{
+        "token":         accessToken,
+        "refresh_token": refreshToken,
+        "token_type":    "Bearer",
+        "expires_in":    int(utils.AccessTokenTTL().Seconds()),
+    }, nil
+}
+
+// revokeTokenFamily revokes every refresh token that belongs to the family
+func revokeTokenFamily(tx *gorm.DB, familyID string) error {
+    return tx.Model(&models.RefreshToken{}).
+        Where("family_id = ? AND revoked_at IS NULL", familyID).
+        Update("revoked_at", time.Now()).Error
+}
+
+// revokeUserSessions revokes every refresh token the user holds
+func revokeUserSessions(tx *gorm.DB, userID uint) error {
+    return tx.Model(&models.RefreshToken{}).
+        Where("user_id = ? AND revoked_at IS NULL", userID).
+        Update("revoked_at", time.Now()).Error
+}
+
+// RefreshToken godoc
+// @Summary      Refresh an access token
+// @Description  Exchange a refresh token for a new access token and a rotated refresh token. Presenting a refresh token that was already used revokes the whole session.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body     refreshTokenInput true "Refresh token"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]string
+// @Failure      401  {object} map[string]string
+// @Router       /token/refresh [post]
+func RefreshToken(c *gin.Context) {
+    var input refreshTokenInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var session gin.H
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        var stored models.RefreshToken
+        if err := tx.Where("token_hash = ?", utils.HashToken(input.RefreshToken)).First(&stored).Error; err != nil {
+            return err
+        }
+        if stored.UsedAt != nil || stored.RevokedAt != nil {
+            return errRefreshTokenReused
+        }
+        if time.Now().After(stored.ExpiresAt) {
+            return gorm.ErrRecordNotFound
+        }
+
+        // Mark the token as used; a concurrent request that already rotated it counts as reuse
+        result := tx.Model(&models.RefreshToken{}).
+            Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", stored.ID).
+            Update("used_at", time.Now())
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            return errRefreshTokenReused
+        }
+
+        var user models.User
+        if err := tx.First(&user, stored.UserID).Error; err != nil {
+            return err
+        }
+        if user.AccountStatus() != models.AccountActive {
+            return errAccountInactive
+        }
+        var err error
+        session, err = issueTokens(tx, user, stored.FamilyID, stored.MFA)
+        return err
+    })
+
+    switch {
+    case err == nil:
+        c.JSON(http.StatusOK, session)
+    case errors.Is(err, errRefreshTokenReused):
+        // The transaction was rolled back, so revoke the family outside of it
+        var stored models.RefreshToken
+        if err := db.DB.Where("token_hash = ?", utils.HashToken(input.RefreshToken)).First(&stored).Error; err == nil {
+            if err := revokeTokenFamily(db.DB, stored.FamilyID); err != nil {
+                logger.Log.Error("Error revoking token family: ", err)
+            }
+            logger.Log.Warnf("Refresh token reuse detected for user %d, session revoked", stored.UserID)
+        }
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+    case errors.Is(err, errAccountInactive):
+        c.JSON(http.StatusForbidden, gin.H{"error": "This account is suspended or banned"})
+    default:
+        logger.Log.Error("Error refreshing token: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+    }
+}
+
+// Logout godoc
+// @Summary      Logout
+// @Description  Revoke the refresh token and every token rotated from the same login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body     refreshTokenInput true "Refresh token"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Router       /logout [post]
+func Logout(c *gin.Context) {
+    var input refreshTokenInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var stored models.RefreshToken
+    if err := db.DB.Where("token_hash = ?", utils.HashToken(input.RefreshToken)).First(&stored).Error; err == nil {
+        if err := revokeTokenFamily(db.DB, stored.FamilyID); err != nil {
+            logger.Log.Error("Error revoking token family: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+            return
+        }
+        logger.Log.Infof("User logged out: %d", stored.UserID)
+    }
+
+    // Unknown tokens are treated as already logged out
+    c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
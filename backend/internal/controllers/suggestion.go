@@ -0,0 +1,264 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// suggestionReputation is awarded to the suggester when a moderator accepts their edit
+const suggestionReputation = 3
+
+var errSuggestionOutdated = errors.New("listing changed since the suggestion was made")
+
+// SuggestEdit godoc
+// @Summary      Suggest an edit
+// @Description  Propose corrections to an approved listing, including its tags, which decide its category. Only the fields that differ from the listing are stored, and a moderator reviews them before they are applied.
+// @Tags         Restaurants
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int                     true  "Restaurant ID"
+// @Param        suggestion  body      models.SuggestEditInput true  "Proposed values"
+// @Success      201         {object}  models.SuggestedEdit
+// @Failure      400         {object}  map[string]string
+// @Failure      404         {object}  map[string]string
+// @Failure      500         {object}  map[string]string
+// @Router       /restaurants/{id}/suggestions [post]
+func SuggestEdit(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+
+    var input models.SuggestEditInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.Where("status = ?", "approved").First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+
+    changes, err := restaurantChanges(restaurant, input.UpdateRestaurantInput)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    current, err := restaurantFields(db.DB, restaurant)
+    if err != nil {
+        logger.Log.Error("Error fetching restaurant: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save suggestion"})
+        return
+    }
+    if input.Tags != nil {
+        tags, err := resolveTags(*input.Tags)
+        if errors.Is(err, errUnknownTag) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
+        if err != nil {
+            logger.Log.Error("Error fetching tags: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save suggestion"})
+            return
+        }
+        if value := tagsField(tags); value != current["tags"] {
+            changes["tags"] = value
+        }
+    }
+    if len(changes) == 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "The suggestion does not change anything"})
+        return
+    }
+
+    suggestion := models.SuggestedEdit{
+        RestaurantID:  restaurant.ID,
+        SuggestedByID: c.GetUint("userID"),
+        Comment:       strings.TrimSpace(input.Comment),
+        Status:        models.EditStatusPending,
+    }
+    for field, value := range changes {
+        suggestion.Changes = append(suggestion.Changes, models.FieldChange{Field: field, From: current[field], To: value})
+    }
+    sort.Slice(suggestion.Changes, func(i, j int) bool { return suggestion.Changes[i].Field < suggestion.Changes[j].Field })
+
+    if err := db.DB.Create(&suggestion).Error; err != nil {
+        logger.Log.Error("Error saving suggestion: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save suggestion"})
+        return
+    }
+
+    logger.Log.Infof("User %d suggested edit %d to restaurant %d", suggestion.SuggestedByID, suggestion.ID, restaurant.ID)
+    c.JSON(http.StatusCreated, suggestion)
+}
+
+// GetPendingSuggestions godoc
+// @Summary      Get pending suggestions
+// @Description  Moderators can get the community-suggested edits waiting for review
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {array}   models.SuggestedEdit
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/suggestions [get]
+func GetPendingSuggestions(c *gin.Context) {
+    suggestions := []models.SuggestedEdit{}
+    if err := db.DB.Where("status = ?", models.EditStatusPending).Order("created_at").Find(&suggestions).Error; err != nil {
+        logger.Log.Error("Error fetching pending suggestions: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pending suggestions"})
+        return
+    }
+    c.JSON(http.StatusOK, suggestions)
+}
+
+// ApproveSuggestion godoc
+// @Summary      Approve a suggestion
+// @Description  Moderators can apply a suggested edit and reward the suggester. Suggestions made against values that have since changed are refused.
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Suggestion ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/suggestions/{id}/approve [put]
+func ApproveSuggestion(c *gin.Context) {
+    reviewSuggestion(c, models.EditStatusApproved)
+}
+
+// RejectSuggestion godoc
+// @Summary      Reject a suggestion
+// @Description  Moderators can discard a suggested edit
+// @Tags         Moderation
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Suggestion ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/restaurants/suggestions/{id}/reject [put]
+func RejectSuggestion(c *gin.Context) {
+    reviewSuggestion(c, models.EditStatusRejected)
+}
+
+// reviewSuggestion closes a pending suggestion with the status, applying it when approved
+func reviewSuggestion(c *gin.Context, status string) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid suggestion ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suggestion ID"})
+        return
+    }
+
+    // geocode before the transaction, which looks the suggestion up again and reports any error
+    var located map[string]interface{}
+    var pending models.SuggestedEdit
+    if status == models.EditStatusApproved && db.DB.First(&pending, id).Error == nil && pending.Status == models.EditStatusPending {
+        var restaurant models.Restaurant
+        if db.DB.First(&restaurant, pending.RestaurantID).Error == nil {
+            located = locateChanges(restaurant, suggestedValues(pending))
+        }
+    }
+
+    reviewerID := c.GetUint("userID")
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        var suggestion models.SuggestedEdit
+        if err := tx.First(&suggestion, id).Error; err != nil {
+            return err
+        }
+        if suggestion.Status != models.EditStatusPending {
+            return errEditNotPending
+        }
+        if status == models.EditStatusApproved {
+            if err := applySuggestion(tx, suggestion, reviewerID, located); err != nil {
+                return err
+            }
+        }
+        return tx.Model(&suggestion).Updates(map[string]interface{}{
+            "status":         status,
+            "reviewed_by_id": reviewerID,
+            "reviewed_at":    time.Now(),
+        }).Error
+    })
+    switch {
+    case errors.Is(err, gorm.ErrRecordNotFound):
+        c.JSON(http.StatusNotFound, gin.H{"error": "Suggestion not found"})
+        return
+    case errors.Is(err, errEditNotPending):
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Suggestion has already been reviewed"})
+        return
+    case errors.Is(err, errSuggestionOutdated):
+        c.JSON(http.StatusConflict, gin.H{"error": "The listing has changed since this suggestion was made"})
+        return
+    case errors.Is(err, errUnknownTag):
+        c.JSON(http.StatusConflict, gin.H{"error": "The suggestion refers to a tag that no longer exists"})
+        return
+    case err != nil:
+        logger.Log.Error("Error reviewing suggestion: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review suggestion"})
+        return
+    }
+
+    logger.Log.Infof("User %d marked suggestion %d as %s", reviewerID, id, status)
+    c.JSON(http.StatusOK, gin.H{"message": "Suggestion " + status})
+}
+
+// suggestedValues returns the values the suggestion proposes by field
+func suggestedValues(suggestion models.SuggestedEdit) map[string]string {
+    values := make(map[string]string, len(suggestion.Changes))
+    for _, change := range suggestion.Changes {
+        values[change.Field] = change.To
+    }
+    return values
+}
+
+// applySuggestion writes the suggested values when the listing still has the values
+// they were suggested against, and rewards the suggester
+func applySuggestion(tx *gorm.DB, suggestion models.SuggestedEdit, reviewerID uint, located map[string]interface{}) error {
+    var restaurant models.Restaurant
+    if err := tx.First(&restaurant, suggestion.RestaurantID).Error; err != nil {
+        return err
+    }
+    current, err := restaurantFields(tx, restaurant)
+    if err != nil {
+        return err
+    }
+    for _, change := range suggestion.Changes {
+        if current[change.Field] != change.From {
+            return errSuggestionOutdated
+        }
+    }
+    if err := applyRestaurantChanges(tx, restaurant.ID, suggestedValues(suggestion), located); err != nil {
+        return err
+    }
+    note := fmt.Sprintf("Suggestion %d approved by user %d", suggestion.ID, reviewerID)
+    if err := recordRevision(tx, restaurant.ID, suggestion.SuggestedByID, models.RevisionEdited, note); err != nil {
+        return err
+    }
+    return tx.Model(&models.User{}).Where("id = ?", suggestion.SuggestedByID).Update("reputation", gorm.Expr("reputation + ?", suggestionReputation)).Error
+}
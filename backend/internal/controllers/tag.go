@@ -0,0 +1,434 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/middlewares"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/taxonomy"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// errUnknownTag is returned for slugs that match no tag, such as those of deleted tags
+// kept in queued edits and revisions
+var errUnknownTag = errors.New("unknown tag")
+
+// resolveTags looks up tags by slug, failing on the first unknown one
+func resolveTags(slugs []string) ([]models.Tag, error) {
+    tags := []models.Tag{}
+    if len(slugs) == 0 {
+        return tags, nil
+    }
+    if err := db.DB.Where("slug IN ?", slugs).Find(&tags).Error; err != nil {
+        return nil, err
+    }
+    for _, slug := range slugs {
+        found := false
+        for _, tag := range tags {
+            found = found || tag.Slug == slug
+        }
+        if !found {
+            return nil, fmt.Errorf("%w %q", errUnknownTag, slug)
+        }
+    }
+    return tags, nil
+}
+
+// primaryCuisine returns the name of the cuisine with the first slug among the tags,
+// which is kept in the restaurant's category for clients that do not know about tags
+// yet. Going by slug rather than the order tags were loaded in keeps the category stable.
+// The category is never set any other way.
+func primaryCuisine(tags []models.Tag) string {
+    var primary *models.Tag
+    for i := range tags {
+        if tags[i].Kind == models.TagKindCuisine && (primary == nil || tags[i].Slug < primary.Slug) {
+            primary = &tags[i]
+        }
+    }
+    if primary == nil {
+        return ""
+    }
+    return primary.Name
+}
+
+// refreshTaggedRestaurants sets the category of the restaurants from their tags again after
+// one of the tags was renamed or removed, recording a revision for each listing whose tags
+// were removed or whose category changed
+func refreshTaggedRestaurants(tx *gorm.DB, restaurantIDs []uint, authorID uint, retagged bool, note string) error {
+    for _, id := range restaurantIDs {
+        var restaurant models.Restaurant
+        if err := tx.Preload("Tags").First(&restaurant, id).Error; err != nil {
+            return err
+        }
+        cuisine := primaryCuisine(restaurant.Tags)
+        if !retagged && cuisine == restaurant.Category {
+            continue
+        }
+        if err := tx.Model(&restaurant).Update("category", cuisine).Error; err != nil {
+            return err
+        }
+        if err := recordRevision(tx, restaurant.ID, authorID, models.RevisionEdited, note); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// taggedRestaurants returns the IDs of the restaurants with the tag
+func taggedRestaurants(tx *gorm.DB, tagID uint) ([]uint, error) {
+    var ids []uint
+    err := tx.Model(&models.Restaurant{}).Where("id IN (SELECT restaurant_id FROM restaurant_tags WHERE tag_id = ?)", tagID).Pluck("id", &ids).Error
+    return ids, err
+}
+
+// tagFilter restricts a restaurant query to the tags, including the tags nested below
+// them. With matchAll a restaurant needs every tag, otherwise any of them.
+func tagFilter(query *gorm.DB, slugs []string, matchAll bool) (*gorm.DB, error) {
+    var all []models.Tag
+    if err := db.DB.Find(&all).Error; err != nil {
+        return nil, err
+    }
+    var groups [][]uint
+    for _, slug := range slugs {
+        var tag *models.Tag
+        for i := range all {
+            if all[i].Slug == slug {
+                tag = &all[i]
+            }
+        }
+        if tag == nil {
+            return nil, fmt.Errorf("%w %q", errUnknownTag, slug)
+        }
+        groups = append(groups, taxonomy.Descendants(all, tag.ID))
+    }
+
+    const tagged = "restaurants.id IN (SELECT restaurant_id FROM restaurant_tags WHERE tag_id IN ?)"
+    if matchAll {
+        for _, ids := range groups {
+            query = query.Where(tagged, ids)
+        }
+        return query, nil
+    }
+    var ids []uint
+    for _, group := range groups {
+        ids = append(ids, group...)
+    }
+    return query.Where(tagged, ids), nil
+}
+
+// ListTags godoc
+// @Summary      List tags
+// @Description  Get the taxonomy of cuisines and tags. Nested entries reference their parent_id.
+// @Tags         Tags
+// @Produce      json
+// @Param        kind  query     string false  "Filter by kind (cuisine or tag)"
+// @Success      200   {array}   models.Tag
+// @Failure      500   {object}  map[string]string
+// @Router       /tags [get]
+func ListTags(c *gin.Context) {
+    query := db.DB.Order("name")
+    if kind := c.Query("kind"); kind != "" {
+        query = query.Where("kind = ?", kind)
+    }
+    tags := []models.Tag{}
+    if err := query.Find(&tags).Error; err != nil {
+        logger.Log.Error("Error fetching tags: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+        return
+    }
+    c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag godoc
+// @Summary      Create a tag
+// @Description  Add a cuisine or tag to the taxonomy, optionally nested under a parent
+// @Tags         Tags
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        tag  body      models.CreateTagInput true "Tag"
+// @Success      201  {object}  models.Tag
+// @Failure      400  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/tags [post]
+func CreateTag(c *gin.Context) {
+    var input models.CreateTagInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    tag := models.Tag{
+        Name:     strings.TrimSpace(input.Name),
+        Slug:     taxonomy.Slugify(input.Slug),
+        Kind:     input.Kind,
+        ParentID: input.ParentID,
+        Aliases:  input.Aliases,
+    }
+    if tag.Slug == "" {
+        tag.Slug = taxonomy.Slugify(tag.Name)
+    }
+    if tag.Slug == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "name must contain letters or digits"})
+        return
+    }
+    if tag.ParentID != nil {
+        if err := db.DB.First(&models.Tag{}, *tag.ParentID).Error; err != nil {
+            c.JSON(http.StatusBadRequest, gin.H{"error": "Parent tag not found"})
+            return
+        }
+    }
+    var existing int64
+    if err := db.DB.Model(&models.Tag{}).Where("slug = ?", tag.Slug).Count(&existing).Error; err != nil {
+        logger.Log.Error("Error checking tag: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+        return
+    }
+    if existing > 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "A tag with this slug already exists"})
+        return
+    }
+
+    if err := db.DB.Create(&tag).Error; err != nil {
+        logger.Log.Error("Error creating tag: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tag"})
+        return
+    }
+    logger.Log.Infof("User %d created tag %s", c.GetUint("userID"), tag.Slug)
+    c.JSON(http.StatusCreated, tag)
+}
+
+// UpdateTag godoc
+// @Summary      Edit a tag
+// @Description  Rename a tag, move it under another parent or change its aliases. The slug never changes so links keep working. Renaming a cuisine renames the category of the restaurants it is primary for, in their history too.
+// @Tags         Tags
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int                   true  "Tag ID"
+// @Param        tag  body      models.UpdateTagInput true  "Fields to change"
+// @Success      200  {object}  models.Tag
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/tags/{id} [patch]
+func UpdateTag(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid tag ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+        return
+    }
+
+    var input models.UpdateTagInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var tag models.Tag
+    if err := db.DB.First(&tag, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+        return
+    }
+
+    var columns []string
+    if input.Name != nil {
+        tag.Name = strings.TrimSpace(*input.Name)
+        columns = append(columns, "name")
+    }
+    if input.Aliases != nil {
+        tag.Aliases = *input.Aliases
+        columns = append(columns, "aliases")
+    }
+    if input.ParentID != nil {
+        tag.ParentID = nil
+        if *input.ParentID != 0 {
+            var all []models.Tag
+            if err := db.DB.Find(&all).Error; err != nil {
+                logger.Log.Error("Error fetching tags: ", err)
+                c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+                return
+            }
+            for _, descendant := range taxonomy.Descendants(all, tag.ID) {
+                if descendant == *input.ParentID {
+                    c.JSON(http.StatusBadRequest, gin.H{"error": "A tag cannot be nested under itself or its children"})
+                    return
+                }
+            }
+            if err := db.DB.First(&models.Tag{}, *input.ParentID).Error; err != nil {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "Parent tag not found"})
+                return
+            }
+            tag.ParentID = input.ParentID
+        }
+        columns = append(columns, "parent_id")
+    }
+    if len(columns) > 0 {
+        err := db.DB.Transaction(func(tx *gorm.DB) error {
+            if err := tx.Model(&tag).Select(columns).Updates(&tag).Error; err != nil {
+                return err
+            }
+            // a renamed cuisine renames the category of the listings it is primary for
+            if input.Name == nil || tag.Kind != models.TagKindCuisine {
+                return nil
+            }
+            ids, err := taggedRestaurants(tx, tag.ID)
+            if err != nil {
+                return err
+            }
+            return refreshTaggedRestaurants(tx, ids, c.GetUint("userID"), false, fmt.Sprintf("Tag %s renamed", tag.Slug))
+        })
+        if err != nil {
+            logger.Log.Error("Error updating tag: ", err)
+            c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tag"})
+            return
+        }
+    }
+    c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag godoc
+// @Summary      Delete a tag
+// @Description  Remove a tag from the taxonomy and from every restaurant, recording the change in their history and updating their category. Tags nested below it move up to its parent.
+// @Tags         Tags
+// @Security     BearerAuth
+// @Param        id   path      int  true  "Tag ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Router       /admin/tags/{id} [delete]
+func DeleteTag(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid tag ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+        return
+    }
+
+    err = db.DB.Transaction(func(tx *gorm.DB) error {
+        var tag models.Tag
+        if err := tx.First(&tag, id).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&models.Tag{}).Where("parent_id = ?", tag.ID).Update("parent_id", tag.ParentID).Error; err != nil {
+            return err
+        }
+        ids, err := taggedRestaurants(tx, tag.ID)
+        if err != nil {
+            return err
+        }
+        if err := tx.Exec("DELETE FROM restaurant_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+            return err
+        }
+        if err := tx.Delete(&tag).Error; err != nil {
+            return err
+        }
+        return refreshTaggedRestaurants(tx, ids, c.GetUint("userID"), true, fmt.Sprintf("Tag %s deleted", tag.Slug))
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error deleting tag: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tag"})
+        return
+    }
+
+    logger.Log.Infof("User %d deleted tag %d", c.GetUint("userID"), id)
+    c.JSON(http.StatusOK, gin.H{"message": "Tag deleted"})
+}
+
+// SetRestaurantTags godoc
+// @Summary      Set a restaurant's tags
+// @Description  Replace the cuisines and tags of a restaurant. Only the creator of the listing and users with the restaurants.edit permission can do this; changes to approved listings are queued for moderation unless made by a moderator.
+// @Tags         Restaurants
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      int                  true  "Restaurant ID"
+// @Param        tags  body      models.SetTagsInput  true  "Tag slugs"
+// @Success      200   {array}   models.Tag
+// @Success      202   {object}  models.RestaurantEdit
+// @Failure      400   {object}  map[string]string
+// @Failure      403   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      500   {object}  map[string]string
+// @Router       /restaurants/{id}/tags [put]
+func SetRestaurantTags(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        logger.Log.Error("Invalid restaurant ID: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid restaurant ID"})
+        return
+    }
+
+    var input models.SetTagsInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    // Input validation
+    if err := utils.Validate.Struct(input); err != nil {
+        logger.Log.Error("Validation error: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var restaurant models.Restaurant
+    if err := db.DB.First(&restaurant, id).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Restaurant not found"})
+        return
+    }
+    if restaurant.CreatedByID != c.GetUint("userID") && !middlewares.HasPermission(c, rbac.RestaurantsEdit) {
+        c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own listings"})
+        return
+    }
+
+    tags, err := resolveTags(input.Tags)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    current, err := restaurantFields(db.DB, restaurant)
+    if err != nil {
+        logger.Log.Error("Error fetching restaurant tags: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set tags"})
+        return
+    }
+    if value := tagsField(tags); value != current["tags"] && !submitRestaurantChanges(c, restaurant, map[string]string{"tags": value}) {
+        return
+    }
+
+    c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+    "testing"
+
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+func TestPrimaryCuisine(t *testing.T) {
+    thai := models.Tag{Name: "Thai", Slug: "thai", Kind: models.TagKindCuisine}
+    indian := models.Tag{Name: "Indian", Slug: "indian", Kind: models.TagKindCuisine}
+    cafe := models.Tag{Name: "Cafe", Slug: "cafe", Kind: models.TagKindTag}
+
+    tests := []struct {
+        name string
+        tags []models.Tag
+        want string
+    }{
+        {"no tags", nil, ""},
+        {"no cuisine", []models.Tag{cafe}, ""},
+        {"one cuisine", []models.Tag{cafe, thai}, "Thai"},
+        {"first by slug", []models.Tag{thai, cafe, indian}, "Indian"},
+        {"order does not matter", []models.Tag{indian, thai, cafe}, "Indian"},
+    }
+    for _, tt := range tests {
+        if got := primaryCuisine(tt.tags); got != tt.want {
+            t.Errorf("%s: primaryCuisine = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestTagsField(t *testing.T) {
+    tests := []struct {
+        slugs []string
+        want  string
+    }{
+        {nil, ""},
+        {[]string{"thai"}, "thai"},
+        {[]string{"thai", "cafe", "indian"}, "cafe,indian,thai"},
+        {[]string{"indian", "thai", "cafe"}, "cafe,indian,thai"},
+    }
+    for _, tt := range tests {
+        tags := []models.Tag{}
+        for _, slug := range tt.slugs {
+            tags = append(tags, models.Tag{Slug: slug})
+        }
+        if got := tagsField(tags); got != tt.want {
+            t.Errorf("tagsField(%v) = %q, want %q", tt.slugs, got, tt.want)
+        }
+    }
+}
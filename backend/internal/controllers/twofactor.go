@@ -0,0 +1,392 @@
+package controllers
+
+import (
+    "crypto/rand"
+    "encoding/base32"
+    "errors"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/metrics"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "golang.org/x/crypto/bcrypt"
+    "gorm.io/gorm"
+)
+
+const recoveryCodeCount = 10
+
+var errInvalidSecondFactor = errors.New("invalid second factor")
+
+type twoFactorCodeInput struct {
+    Code string `json:"code" binding:"required"`
+}
+
+type disableTwoFactorInput struct {
+    Password string `json:"password" binding:"required"`
+    Code     string `json:"code" binding:"required"`
+}
+
+type loginTwoFactorInput struct {
+    MFAToken     string `json:"mfa_token" binding:"required"`
+    Code         string `json:"code"`
+    RecoveryCode string `json:"recovery_code"`
+}
+
+// normalizeRecoveryCode strips formatting so "ABCDE-FGHIJ" and "abcdefghij" match
+func normalizeRecoveryCode(code string) string {
+    code = strings.ToLower(strings.TrimSpace(code))
+    code = strings.ReplaceAll(code, "-", "")
+    return strings.ReplaceAll(code, " ", "")
+}
+
+// generateRecoveryCodes replaces the user's recovery codes and returns the new plain text codes
+func generateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+    if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+        return nil, err
+    }
+    encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+    codes := make([]string, 0, recoveryCodeCount)
+    records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+    for i := 0; i < recoveryCodeCount; i++ {
+        b := make([]byte, 7)
+        if _, err := rand.Read(b); err != nil {
+            return nil, err
+        }
+        code := strings.ToLower(encoding.EncodeToString(b))[:10]
+        codes = append(codes, code[:5]+"-"+code[5:])
+        records = append(records, models.RecoveryCode{UserID: userID, CodeHash: utils.HashToken(code)})
+    }
+    if err := tx.Create(&records).Error; err != nil {
+        return nil, err
+    }
+    return codes, nil
+}
+
+// verifySecondFactor checks a TOTP code or, when code is empty, a recovery code.
+// Accepted TOTP steps and recovery codes cannot be used again; both are claimed with a
+// conditional update so that concurrent requests with the same code cannot both pass.
+func verifySecondFactor(tx *gorm.DB, user *models.User, code, recoveryCode string) (bool, error) {
+    if code != "" {
+        step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+        if !ok || step <= user.TOTPLastStep {
+            return false, nil
+        }
+        result := tx.Model(&models.User{}).Where("id = ? AND totp_last_step < ?", user.ID, step).Update("totp_last_step", step)
+        if result.Error != nil || result.RowsAffected != 1 {
+            return false, result.Error
+        }
+        user.TOTPLastStep = step
+        return true, nil
+    }
+    if recoveryCode == "" {
+        return false, nil
+    }
+
+    result := tx.Model(&models.RecoveryCode{}).
+        Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, utils.HashToken(normalizeRecoveryCode(recoveryCode))).
+        Update("used_at", time.Now())
+    return result.RowsAffected > 0, result.Error
+}
+
+// SetupTwoFactor godoc
+// @Summary      Start two-factor enrollment
+// @Description  Generate a TOTP secret and otpauth:// URI for an authenticator app. Enrollment completes at /2fa/confirm.
+// @Tags         Two-Factor
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Router       /2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if user.TOTPEnabled {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+        return
+    }
+
+    secret, err := utils.GenerateTOTPSecret()
+    if err != nil {
+        logger.Log.Error("Error generating TOTP secret: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    if err := db.DB.Model(&user).Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0}).Error; err != nil {
+        logger.Log.Error("Error saving TOTP secret: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "secret":      secret,
+        "otpauth_uri": utils.TOTPURI(configs.GetEnv("TOTP_ISSUER", "FoodieBaba"), user.Email, secret),
+    })
+}
+
+// ConfirmTwoFactor godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Enable two-factor authentication with a code from the authenticator app and receive recovery codes
+// @Tags         Two-Factor
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body     twoFactorCodeInput true "TOTP code"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]string
+// @Router       /2fa/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+    var input twoFactorCodeInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if user.TOTPEnabled {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is already enabled"})
+        return
+    }
+    if user.TOTPSecret == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Start enrollment at /2fa/setup first"})
+        return
+    }
+
+    var codes []string
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        ok, err := verifySecondFactor(tx, &user, input.Code, "")
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return errInvalidSecondFactor
+        }
+        if err := tx.Model(&user).Update("totp_enabled", true).Error; err != nil {
+            return err
+        }
+        codes, err = generateRecoveryCodes(tx, user.ID)
+        return err
+    })
+    if errors.Is(err, errInvalidSecondFactor) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid code"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error enabling two-factor authentication: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    logger.Log.Infof("Two-factor authentication enabled for user %d", user.ID)
+    c.JSON(http.StatusOK, gin.H{
+        "message":        "Two-factor authentication enabled",
+        "recovery_codes": codes,
+    })
+}
+
+// DisableTwoFactor godoc
+// @Summary      Disable two-factor authentication
+// @Description  Turn off two-factor authentication. Requires the password and a current TOTP code.
+// @Tags         Two-Factor
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body     disableTwoFactorInput true "Password and TOTP code"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Failure      401  {object} map[string]string
+// @Router       /2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+    var input disableTwoFactorInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if !user.TOTPEnabled {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+        return
+    }
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+        return
+    }
+
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        ok, err := verifySecondFactor(tx, &user, input.Code, "")
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return errInvalidSecondFactor
+        }
+        if err := tx.Model(&user).Updates(map[string]interface{}{"totp_enabled": false, "totp_secret": ""}).Error; err != nil {
+            return err
+        }
+        return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+    })
+    if errors.Is(err, errInvalidSecondFactor) {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error disabling two-factor authentication: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    logger.Log.Infof("Two-factor authentication disabled for user %d", user.ID)
+    c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replace all recovery codes. Requires a current TOTP code.
+// @Tags         Two-Factor
+// @Security     BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        body body     twoFactorCodeInput true "TOTP code"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]string
+// @Failure      401  {object} map[string]string
+// @Router       /2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+    var input twoFactorCodeInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, c.GetUint("userID")).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if !user.TOTPEnabled {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+        return
+    }
+
+    var codes []string
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        ok, err := verifySecondFactor(tx, &user, input.Code, "")
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return errInvalidSecondFactor
+        }
+        codes, err = generateRecoveryCodes(tx, user.ID)
+        return err
+    })
+    if errors.Is(err, errInvalidSecondFactor) {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error regenerating recovery codes: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// LoginTwoFactor godoc
+// @Summary      Complete a two-factor login
+// @Description  Exchange the mfa_token from /login and a TOTP or recovery code for an access token and a refresh token
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        body body     loginTwoFactorInput true "Challenge token and code"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]string
+// @Failure      401  {object} map[string]string
+// @Failure      429  {object} map[string]string
+// @Router       /login/2fa [post]
+func LoginTwoFactor(c *gin.Context) {
+    var input loginTwoFactorInput
+    if err := c.ShouldBindJSON(&input); err != nil {
+        logger.Log.Error("Invalid input: ", err)
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if input.Code == "" && input.RecoveryCode == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code required"})
+        return
+    }
+
+    userID, err := utils.ParseMFAToken(input.MFAToken)
+    if err != nil {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+        return
+    }
+
+    var user models.User
+    if err := db.DB.First(&user, userID).Error; err != nil || !user.TOTPEnabled {
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired MFA token"})
+        return
+    }
+
+    // Guessing codes counts towards the same lockout as guessing passwords
+    accountKey := accountThrottleKey(user.Email)
+    if retryAfter := loginRetryAfter(accountKey, accountThrottlePolicy()); retryAfter > 0 {
+        metrics.LoginFailures.WithLabelValues("throttled").Inc()
+        c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts. Try again later"})
+        return
+    }
+
+    ok, err := verifySecondFactor(db.DB, &user, input.Code, input.RecoveryCode)
+    if err != nil {
+        logger.Log.Error("Error verifying second factor: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    if !ok {
+        logger.Log.Warnf("Invalid second factor for user %d", user.ID)
+        metrics.LoginFailures.WithLabelValues("invalid_mfa").Inc()
+        recordLoginFailure(accountKey, accountThrottlePolicy())
+        c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+        return
+    }
+
+    if err := resetLoginThrottle(accountKey); err != nil {
+        logger.Log.Error("Error resetting login throttle: ", err)
+    }
+    if rejectInactiveAccount(c, user) {
+        return
+    }
+
+    session, err := issueSession(user, true)
+    if err != nil {
+        logger.Log.Error("Error creating session: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    logger.Log.Infof("User logged in with two-factor authentication: %s", user.Email)
+    c.JSON(http.StatusOK, session)
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "net/http"
+    "strconv"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+const maxPageSize = 100
+
+// pagination reads the page and limit query parameters
+func pagination(c *gin.Context) (page, limit, offset int) {
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page < 1 {
+        page = 1
+    }
+    limit, err = strconv.Atoi(c.DefaultQuery("limit", "10"))
+    if err != nil || limit < 1 {
+        limit = 10
+    }
+    if limit > maxPageSize {
+        limit = maxPageSize
+    }
+    return page, limit, (page - 1) * limit
+}
+
+// publicReviewsQuery selects a user's reviews of approved restaurants
+func publicReviewsQuery(userID uint) *gorm.DB {
+    return db.DB.Model(&models.Review{}).
+        Select("reviews.id, reviews.created_at, reviews.rating, reviews.comment, reviews.restaurant_id, restaurants.name AS restaurant_name").
+        Joins("JOIN restaurants ON restaurants.id = reviews.restaurant_id AND restaurants.deleted_at IS NULL").
+        Where("reviews.user_id = ? AND restaurants.status = ?", userID, "approved")
+}
+
+// publicRestaurantsQuery selects a user's approved listings
+func publicRestaurantsQuery(userID uint) *gorm.DB {
+    return db.DB.Model(&models.Restaurant{}).
+        Select("id, created_at, name, address, category").
+        Where("created_by_id = ? AND status = ?", userID, "approved")
+}
+
+// findPublicUser looks up a user by username, responding with 404 when there is none
+func findPublicUser(c *gin.Context) (models.User, bool) {
+    var user models.User
+    if err := db.DB.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return user, false
+    }
+    return user, true
+}
+
+// GetUserProfile godoc
+// @Summary      Get a public user profile
+// @Description  Get a user's public profile with contribution counts and the first page of their reviews and approved restaurants
+// @Tags         Users
+// @Produce      json
+// @Param        username path      string true  "Username"
+// @Param        limit    query     int    false "Page size of the embedded lists"
+// @Success      200      {object}  models.PublicProfile
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /users/{username} [get]
+func GetUserProfile(c *gin.Context) {
+    user, ok := findPublicUser(c)
+    if !ok {
+        return
+    }
+    _, limit, _ := pagination(c)
+
+    profile := models.PublicProfile{
+        Username:    user.Username,
+        DisplayName: user.DisplayName,
+        Bio:         user.Bio,
+        AvatarURL:   user.AvatarURL,
+        HomeCity:    user.HomeCity,
+        JoinedAt:    user.CreatedAt,
+        Reputation:  user.Reputation,
+        Reviews:     []models.PublicReview{},
+        Restaurants: []models.PublicRestaurant{},
+    }
+
+    err := publicRestaurantsQuery(user.ID).Count(&profile.ApprovedListings).Error
+    if err == nil {
+        err = publicReviewsQuery(user.ID).Count(&profile.ReviewCount).Error
+    }
+    if err == nil {
+        err = publicReviewsQuery(user.ID).Order("reviews.created_at DESC").Limit(limit).Scan(&profile.Reviews).Error
+    }
+    if err == nil {
+        err = publicRestaurantsQuery(user.ID).Order("created_at DESC").Limit(limit).Scan(&profile.Restaurants).Error
+    }
+    if err != nil {
+        logger.Log.Error("Error fetching user profile: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+        return
+    }
+
+    c.JSON(http.StatusOK, profile)
+}
+
+// GetUserReviews godoc
+// @Summary      List a user's reviews
+// @Description  Get a page of the reviews a user wrote for approved restaurants
+// @Tags         Users
+// @Produce      json
+// @Param        username path      string true  "Username"
+// @Param        page     query     int    false "Page number"
+// @Param        limit    query     int    false "Page size"
+// @Success      200      {array}   models.PublicReview
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /users/{username}/reviews [get]
+func GetUserReviews(c *gin.Context) {
+    user, ok := findPublicUser(c)
+    if !ok {
+        return
+    }
+    _, limit, offset := pagination(c)
+
+    reviews := []models.PublicReview{}
+    if err := publicReviewsQuery(user.ID).Order("reviews.created_at DESC").Limit(limit).Offset(offset).Scan(&reviews).Error; err != nil {
+        logger.Log.Error("Error fetching user reviews: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+        return
+    }
+    c.JSON(http.StatusOK, reviews)
+}
+
+// GetUserRestaurants godoc
+// @Summary      List a user's restaurants
+// @Description  Get a page of the approved restaurants a user added
+// @Tags         Users
+// @Produce      json
+// @Param        username path      string true  "Username"
+// @Param        page     query     int    false "Page number"
+// @Param        limit    query     int    false "Page size"
+// @Success      200      {array}   models.PublicRestaurant
+// @Failure      404      {object}  map[string]string
+// @Failure      500      {object}  map[string]string
+// @Router       /users/{username}/restaurants [get]
+func GetUserRestaurants(c *gin.Context) {
+    user, ok := findPublicUser(c)
+    if !ok {
+        return
+    }
+    _, limit, offset := pagination(c)
+
+    restaurants := []models.PublicRestaurant{}
+    if err := publicRestaurantsQuery(user.ID).Order("created_at DESC").Limit(limit).Offset(offset).Scan(&restaurants).Error; err != nil {
+        logger.Log.Error("Error fetching user restaurants: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch restaurants"})
+        return
+    }
+    c.JSON(http.StatusOK, restaurants)
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "time"
+
+    "gorm.io/gorm"
+)
+
+// frontendURL builds a link to a page of the web app
+func frontendURL(path string) string {
+    return configs.GetEnv("FRONTEND_URL", "http://localhost:5173") + path
+}
+
+// createUserToken invalidates earlier unused tokens with the same purpose and returns a new raw token
+func createUserToken(tx *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+    raw, err := utils.GenerateRandomToken(32)
+    if err != nil {
+        return "", err
+    }
+    if err := tx.Model(&models.UserToken{}).
+        Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+        Update("used_at", time.Now()).Error; err != nil {
+        return "", err
+    }
+    token := models.UserToken{
+        UserID:    userID,
+        Purpose:   purpose,
+        TokenHash: utils.HashToken(raw),
+        ExpiresAt: time.Now().Add(ttl),
+    }
+    if err := tx.Create(&token).Error; err != nil {
+        return "", err
+    }
+    return raw, nil
+}
+
+// consumeUserToken marks a valid token as used and returns it.
+// Unknown, expired and already used tokens return gorm.ErrRecordNotFound.
+func consumeUserToken(tx *gorm.DB, raw, purpose string) (models.UserToken, error) {
+    var token models.UserToken
+    if err := tx.Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", utils.HashToken(raw), purpose, time.Now()).
+        First(&token).Error; err != nil {
+        return token, err
+    }
+    result := tx.Model(&models.UserToken{}).
+        Where("id = ? AND used_at IS NULL", token.ID).
+        Update("used_at", time.Now())
+    if result.Error != nil {
+        return token, result.Error
+    }
+    if result.RowsAffected == 0 {
+        return token, gorm.ErrRecordNotFound
+    }
+    return token, nil
+}
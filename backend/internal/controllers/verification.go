@@ -0,0 +1,132 @@
+package controllers
+
+import (
+    "errors"
+    "fmt"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "github.com/pp00x/foodiebaba/pkg/mailer"
+    "net/http"
+    "net/url"
+    "strconv"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "gorm.io/gorm"
+)
+
+// sendVerificationEmail issues a new email verification token and mails the link to the user
+func sendVerificationEmail(user models.User) error {
+    ttl := configs.GetDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+    token, err := createUserToken(db.DB, user.ID, models.TokenPurposeEmailVerification, ttl)
+    if err != nil {
+        return err
+    }
+
+    link := frontendURL("/verify-email?token=" + url.QueryEscape(token))
+    return mailer.Send(mailer.Message{
+        To:      user.Email,
+        Subject: "Verify your FoodieBaba email address",
+        Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address to start adding restaurants and reviews. The link expires in %s.\n\n%s\n",
+            user.Username, ttl, link),
+    })
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email address
+// @Description  Confirm the email address of the user the verification token was sent to
+// @Tags         Auth
+// @Produce      json
+// @Param        token query    string true "Verification token"
+// @Success      200   {object} map[string]string
+// @Failure      400   {object} map[string]string
+// @Router       /verify-email [get]
+func VerifyEmail(c *gin.Context) {
+    raw := c.Query("token")
+    if raw == "" {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Verification token required"})
+        return
+    }
+
+    err := db.DB.Transaction(func(tx *gorm.DB) error {
+        token, err := consumeUserToken(tx, raw, models.TokenPurposeEmailVerification)
+        if err != nil {
+            return err
+        }
+        return tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+            "email_verified":    true,
+            "email_verified_at": time.Now(),
+        }).Error
+    })
+    if errors.Is(err, gorm.ErrRecordNotFound) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification token"})
+        return
+    }
+    if err != nil {
+        logger.Log.Error("Error verifying email: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerificationEmail godoc
+// @Summary      Resend verification email
+// @Description  Send a new verification link to the logged in user. Requests are throttled.
+// @Tags         Auth
+// @Security     BearerAuth
+// @Produce      json
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]string
+// @Failure      429  {object} map[string]string
+// @Router       /verify-email/resend [post]
+func ResendVerificationEmail(c *gin.Context) {
+    userID := c.GetUint("userID")
+
+    var user models.User
+    if err := db.DB.First(&user, userID).Error; err != nil {
+        logger.Log.Error("Error fetching user: ", err)
+        c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+        return
+    }
+    if user.EmailVerified {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Email already verified"})
+        return
+    }
+
+    // Throttle: a minimum interval between emails and a daily cap
+    interval := configs.GetDuration("EMAIL_VERIFICATION_RESEND_INTERVAL", time.Minute)
+    var last models.UserToken
+    err := db.DB.Where("user_id = ? AND purpose = ?", userID, models.TokenPurposeEmailVerification).
+        Order("created_at DESC").First(&last).Error
+    if err == nil {
+        if wait := time.Until(last.CreatedAt.Add(interval)); wait > 0 {
+            c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+            c.JSON(http.StatusTooManyRequests, gin.H{"error": "Please wait before requesting another verification email"})
+            return
+        }
+    }
+    var sentToday int64
+    if err := db.DB.Model(&models.UserToken{}).
+        Where("user_id = ? AND purpose = ? AND created_at > ?", userID, models.TokenPurposeEmailVerification, time.Now().Add(-24*time.Hour)).
+        Count(&sentToday).Error; err != nil {
+        logger.Log.Error("Error counting verification emails: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+        return
+    }
+    if sentToday >= int64(configs.GetInt("EMAIL_VERIFICATION_DAILY_LIMIT", 5)) {
+        c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many verification emails requested today"})
+        return
+    }
+
+    if err := sendVerificationEmail(user); err != nil {
+        logger.Log.Error("Error sending verification email: ", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
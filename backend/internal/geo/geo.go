@@ -0,0 +1,43 @@
+package geo
+
+import (
+    "math"
+)
+
+// EarthRadiusKm is the mean radius of the earth
+const EarthRadiusKm = 6371.0
+
+// kmPerDegree is the length of one degree of latitude
+const kmPerDegree = EarthRadiusKm * math.Pi / 180
+
+// DistanceSQL computes the haversine distance in km between the latitude and longitude
+// columns and a point. Its placeholders take the point's latitude, latitude and longitude.
+// LEAST guards ASIN against rounding errors pushing its argument above 1.
+const DistanceSQL = "2 * 6371 * ASIN(LEAST(1, SQRT(POWER(SIN(RADIANS(latitude - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - ?) / 2), 2))))"
+
+// Box is a latitude/longitude rectangle
+type Box struct {
+    MinLat, MaxLat float64
+    MinLng, MaxLng float64
+    // WrapsLng is set when the box crosses the poles or the antimeridian, in which
+    // case the longitude bounds should not be used for filtering
+    WrapsLng bool
+}
+
+// BoundingBox returns a box that contains every point within radiusKm of the point,
+// cheap to filter on with ordinary indexes before computing exact distances
+func BoundingBox(lat, lng, radiusKm float64) Box {
+    dLat := radiusKm / kmPerDegree
+    box := Box{MinLat: lat - dLat, MaxLat: lat + dLat}
+    if box.MinLat <= -90 || box.MaxLat >= 90 {
+        box.WrapsLng = true
+        return box
+    }
+    // the circle is widest slightly closer to the pole than its centre, so the longitude
+    // span is taken from the spherical formula rather than scaled at the centre's latitude
+    dLng := math.Asin(math.Sin(radiusKm/EarthRadiusKm)/math.Cos(lat*math.Pi/180)) * 180 / math.Pi
+    box.MinLng, box.MaxLng = lng-dLng, lng+dLng
+    box.WrapsLng = box.MinLng < -180 || box.MaxLng > 180
+    return box
+}
+
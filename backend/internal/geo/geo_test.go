@@ -0,0 +1,68 @@
+package geo
+
+import (
+    "math"
+    "testing"
+)
+
+// destination returns the point distanceKm away from the point along the bearing in degrees
+func destination(lat, lng, distanceKm, bearing float64) (float64, float64) {
+    φ, λ, θ := lat*math.Pi/180, lng*math.Pi/180, bearing*math.Pi/180
+    δ := distanceKm / EarthRadiusKm
+    φ2 := math.Asin(math.Sin(φ)*math.Cos(δ) + math.Cos(φ)*math.Sin(δ)*math.Cos(θ))
+    λ2 := λ + math.Atan2(math.Sin(θ)*math.Sin(δ)*math.Cos(φ), math.Cos(δ)-math.Sin(φ)*math.Sin(φ2))
+    lng2 := math.Mod(λ2*180/math.Pi+540, 360) - 180
+    return φ2 * 180 / math.Pi, lng2
+}
+
+func TestBoundingBoxContainsRadius(t *testing.T) {
+    tests := []struct {
+        name     string
+        lat, lng float64
+        radiusKm float64
+    }{
+        {"equator", 0, 0, 10},
+        {"Delhi", 28.6139, 77.2090, 5},
+        {"Buenos Aires", -34.6037, -58.3816, 25},
+        {"Oslo", 59.9139, 10.7522, 50},
+        {"Longyearbyen", 78.2232, 15.6267, 5},
+        {"large radius", 45, 90, 500},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            box := BoundingBox(tt.lat, tt.lng, tt.radiusKm)
+            if box.WrapsLng {
+                t.Fatalf("BoundingBox(%v, %v, %v) wraps", tt.lat, tt.lng, tt.radiusKm)
+            }
+            for bearing := 0.0; bearing < 360; bearing += 5 {
+                // just inside the radius, as points due north and south land on the box's edges
+                lat, lng := destination(tt.lat, tt.lng, tt.radiusKm*0.9999, bearing)
+                if lat < box.MinLat || lat > box.MaxLat || lng < box.MinLng || lng > box.MaxLng {
+                    t.Errorf("point (%v, %v) at bearing %v is outside %+v", lat, lng, bearing, box)
+                }
+            }
+        })
+    }
+}
+
+func TestBoundingBoxWraps(t *testing.T) {
+    tests := []struct {
+        name     string
+        lat, lng float64
+        radiusKm float64
+        want     bool
+    }{
+        {"north pole", 89.99, 0, 5, true},
+        {"south pole", -89.99, 120, 5, true},
+        {"radius past the pole", 85, 0, 1000, true},
+        {"antimeridian east", 0, 179.99, 5, true},
+        {"antimeridian west", -17, -179.99, 5, true},
+        {"near the antimeridian", 0, 179, 5, false},
+        {"far from both", 48.8566, 2.3522, 100, false},
+    }
+    for _, tt := range tests {
+        if got := BoundingBox(tt.lat, tt.lng, tt.radiusKm).WrapsLng; got != tt.want {
+            t.Errorf("%s: BoundingBox(%v, %v, %v).WrapsLng = %v, want %v", tt.name, tt.lat, tt.lng, tt.radiusKm, got, tt.want)
+        }
+    }
+}
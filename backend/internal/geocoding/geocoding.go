@@ -0,0 +1,54 @@
+package geocoding
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/pp00x/foodiebaba/configs"
+)
+
+// ErrNotFound is returned when the address matches no place
+var ErrNotFound = errors.New("address not found")
+
+// Result is the location of an address with its normalized components
+type Result struct {
+    Latitude   float64 `json:"latitude"`
+    Longitude  float64 `json:"longitude"`
+    Street     string  `json:"street"`
+    City       string  `json:"city"`
+    PostalCode string  `json:"postal_code"`
+    Country    string  `json:"country"`
+}
+
+// Geocoder turns free-text addresses into coordinates
+type Geocoder interface {
+    Geocode(ctx context.Context, address string) (Result, error)
+}
+
+// Default is nil when geocoding is disabled
+var Default Geocoder
+
+// Init configures the global geocoder from GEOCODER ("nominatim", "static" or "none")
+func Init() error {
+    switch configs.GetEnv("GEOCODER", "none") {
+    case "nominatim":
+        Default = &NominatimGeocoder{
+            BaseURL:   configs.GetEnv("GEOCODER_URL", "https://nominatim.openstreetmap.org"),
+            UserAgent: configs.GetEnv("GEOCODER_USER_AGENT", "foodiebaba"),
+            Email:     configs.GetEnv("GEOCODER_EMAIL", ""),
+            Client:    &http.Client{Timeout: 10 * time.Second},
+            Interval:  configs.GetDuration("GEOCODER_INTERVAL", time.Second),
+        }
+    case "static":
+        geocoder, err := LoadStaticGeocoder(configs.GetEnv("GEOCODER_STATIC_FILE", "geocoder.json"))
+        if err != nil {
+            return err
+        }
+        Default = geocoder
+    default:
+        Default = nil
+    }
+    return nil
+}
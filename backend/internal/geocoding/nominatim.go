@@ -0,0 +1,116 @@
+package geocoding
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// NominatimGeocoder uses the search API of Nominatim or a compatible service
+type NominatimGeocoder struct {
+    BaseURL   string
+    UserAgent string // Nominatim's usage policy requires an identifying user agent
+    Email     string
+    Client    *http.Client
+    Interval  time.Duration // minimum time between requests; the public server allows one per second
+
+    mu   sync.Mutex
+    last time.Time
+}
+
+type nominatimPlace struct {
+    Lat     string            `json:"lat"`
+    Lon     string            `json:"lon"`
+    Address map[string]string `json:"address"`
+}
+
+// wait blocks until Interval has passed since the previous request, so concurrent
+// lookups are sent one after another
+func (g *NominatimGeocoder) wait(ctx context.Context) error {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    if delay := time.Until(g.last.Add(g.Interval)); delay > 0 {
+        timer := time.NewTimer(delay)
+        defer timer.Stop()
+        select {
+        case <-timer.C:
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+    g.last = time.Now()
+    return nil
+}
+
+func (g *NominatimGeocoder) Geocode(ctx context.Context, address string) (Result, error) {
+    if err := g.wait(ctx); err != nil {
+        return Result{}, err
+    }
+    query := url.Values{
+        "q":              {address},
+        "format":         {"jsonv2"},
+        "addressdetails": {"1"},
+        "limit":          {"1"},
+    }
+    if g.Email != "" {
+        query.Set("email", g.Email)
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(g.BaseURL, "/")+"/search?"+query.Encode(), nil)
+    if err != nil {
+        return Result{}, err
+    }
+    req.Header.Set("User-Agent", g.UserAgent)
+    req.Header.Set("Accept", "application/json")
+
+    resp, err := g.Client.Do(req)
+    if err != nil {
+        return Result{}, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return Result{}, fmt.Errorf("geocoder returned %s", resp.Status)
+    }
+
+    var places []nominatimPlace
+    if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+        return Result{}, err
+    }
+    if len(places) == 0 {
+        return Result{}, ErrNotFound
+    }
+    place := places[0]
+    lat, err := strconv.ParseFloat(place.Lat, 64)
+    if err != nil {
+        return Result{}, fmt.Errorf("invalid latitude %q", place.Lat)
+    }
+    lng, err := strconv.ParseFloat(place.Lon, 64)
+    if err != nil {
+        return Result{}, fmt.Errorf("invalid longitude %q", place.Lon)
+    }
+
+    return Result{
+        Latitude:   lat,
+        Longitude:  lng,
+        Street:     strings.TrimSpace(first(place.Address, "house_number") + " " + first(place.Address, "road", "pedestrian", "footway")),
+        City:       first(place.Address, "city", "town", "village", "municipality", "hamlet"),
+        PostalCode: first(place.Address, "postcode"),
+        Country:    strings.ToUpper(first(place.Address, "country_code")),
+    }, nil
+}
+
+// first returns the first of the keys that is set, since Nominatim names
+// components differently depending on the kind of place
+func first(address map[string]string, keys ...string) string {
+    for _, key := range keys {
+        if value := address[key]; value != "" {
+            return value
+        }
+    }
+    return ""
+}
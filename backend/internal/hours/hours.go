@@ -0,0 +1,218 @@
+package hours
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/pp00x/foodiebaba/internal/models"
+    "gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+// lookahead bounds how many days Status searches for the next opening or closing
+const lookahead = 14
+
+// OpenAtSQL matches restaurants that are open at a point in time. It takes the time as its
+// only placeholder and evaluates it in each restaurant's time zone, following the same rules
+// as Schedule: exceptions replace the weekly hours of their date and intervals that close
+// at or before they open continue past midnight.
+var OpenAtSQL = `EXISTS (
+    SELECT 1
+    FROM (SELECT CAST(? AS timestamptz) AT TIME ZONE COALESCE(NULLIF(restaurants.time_zone, ''), 'UTC') AS t) AS l
+    JOIN opening_hours h ON h.restaurant_id = restaurants.id AND NOT h.closed
+    WHERE (
+        ` + appliesSQL("CAST(l.t AS date)") + `
+        AND h.opens <= EXTRACT(HOUR FROM l.t) * 60 + EXTRACT(MINUTE FROM l.t)
+        AND (h.closes > EXTRACT(HOUR FROM l.t) * 60 + EXTRACT(MINUTE FROM l.t) OR h.closes <= h.opens)
+    ) OR (
+        ` + appliesSQL("CAST(l.t AS date) - 1") + `
+        AND h.closes <= h.opens
+        AND EXTRACT(HOUR FROM l.t) * 60 + EXTRACT(MINUTE FROM l.t) < h.closes
+    )
+)`
+
+// appliesSQL matches hours rows that are in effect on the day
+func appliesSQL(day string) string {
+    date := "to_char(" + day + ", 'YYYY-MM-DD')"
+    return `(h.date = ` + date + ` OR (h.date IS NULL AND h.weekday = EXTRACT(DOW FROM ` + day + `)
+            AND NOT EXISTS (SELECT 1 FROM opening_hours e WHERE e.restaurant_id = h.restaurant_id AND e.date = ` + date + `)))`
+}
+
+// ParseClock parses "HH:MM" into minutes after midnight. "24:00" is accepted as midnight.
+func ParseClock(value string) (int, error) {
+    parts := strings.Split(value, ":")
+    if len(parts) == 2 && len(parts[0]) == 2 && len(parts[1]) == 2 {
+        h, errH := strconv.Atoi(parts[0])
+        m, errM := strconv.Atoi(parts[1])
+        if errH == nil && errM == nil && m >= 0 && m < 60 && (h >= 0 && h < 24 || h == 24 && m == 0) {
+            return (h*60 + m) % (24 * 60), nil
+        }
+    }
+    return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+}
+
+// FormatClock formats minutes after midnight as "HH:MM"
+func FormatClock(minutes int) string {
+    return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// ValidTimeZone reports whether both Go and Postgres know the time zone, since schedules
+// are evaluated in Go and OpenAtSQL converts times with AT TIME ZONE. Go also accepts
+// names such as "Local" that mean nothing to Postgres.
+func ValidTimeZone(tx *gorm.DB, name string) (bool, error) {
+    if _, err := time.LoadLocation(name); err != nil || name == "" || name == "Local" {
+        return false, nil
+    }
+    var known bool
+    err := tx.Raw("SELECT EXISTS (SELECT 1 FROM pg_timezone_names WHERE name = ?)", name).Scan(&known).Error
+    return known, err
+}
+
+// Rows converts a schedule into hours rows for the restaurant. Check its time zone with
+// ValidTimeZone first.
+func Rows(restaurantID uint, schedule models.OpeningHoursSchedule) ([]models.OpeningHours, error) {
+    rows := []models.OpeningHours{}
+    for _, w := range schedule.Weekly {
+        opens, err := ParseClock(w.Opens)
+        if err != nil {
+            return nil, err
+        }
+        closes, err := ParseClock(w.Closes)
+        if err != nil {
+            return nil, err
+        }
+        day := w.Day
+        rows = append(rows, models.OpeningHours{RestaurantID: restaurantID, Weekday: &day, Opens: opens, Closes: closes})
+    }
+    for _, e := range schedule.Exceptions {
+        if _, err := time.Parse(dateLayout, e.Date); err != nil {
+            return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", e.Date)
+        }
+        date := e.Date
+        row := models.OpeningHours{RestaurantID: restaurantID, Date: &date, Closed: e.Closed}
+        if !e.Closed {
+            var err error
+            if row.Opens, err = ParseClock(e.Opens); err != nil {
+                return nil, err
+            }
+            if row.Closes, err = ParseClock(e.Closes); err != nil {
+                return nil, err
+            }
+        }
+        rows = append(rows, row)
+    }
+    return rows, nil
+}
+
+// View converts hours rows back into a schedule
+func View(timeZone string, rows []models.OpeningHours) models.OpeningHoursSchedule {
+    schedule := models.OpeningHoursSchedule{TimeZone: timeZone, Weekly: []models.WeeklyHours{}, Exceptions: []models.HoursException{}}
+    for _, row := range rows {
+        switch {
+        case row.Date != nil && row.Closed:
+            schedule.Exceptions = append(schedule.Exceptions, models.HoursException{Date: *row.Date, Closed: true})
+        case row.Date != nil:
+            schedule.Exceptions = append(schedule.Exceptions, models.HoursException{Date: *row.Date, Opens: FormatClock(row.Opens), Closes: FormatClock(row.Closes)})
+        case row.Weekday != nil:
+            schedule.Weekly = append(schedule.Weekly, models.WeeklyHours{Day: *row.Weekday, Opens: FormatClock(row.Opens), Closes: FormatClock(row.Closes)})
+        }
+    }
+    sort.SliceStable(schedule.Weekly, func(i, j int) bool {
+        a, b := schedule.Weekly[i], schedule.Weekly[j]
+        return a.Day < b.Day || a.Day == b.Day && a.Opens < b.Opens
+    })
+    sort.SliceStable(schedule.Exceptions, func(i, j int) bool { return schedule.Exceptions[i].Date < schedule.Exceptions[j].Date })
+    return schedule
+}
+
+// Schedule answers whether a restaurant is open from its hours rows
+type Schedule struct {
+    location *time.Location
+    weekly   map[time.Weekday][]models.OpeningHours
+    dated    map[string][]models.OpeningHours
+}
+
+// NewSchedule builds a schedule; an empty or unknown time zone is treated as UTC
+func NewSchedule(timeZone string, rows []models.OpeningHours) *Schedule {
+    location, err := time.LoadLocation(timeZone)
+    if err != nil || timeZone == "" {
+        location = time.UTC
+    }
+    s := &Schedule{location: location, weekly: map[time.Weekday][]models.OpeningHours{}, dated: map[string][]models.OpeningHours{}}
+    for _, row := range rows {
+        switch {
+        case row.Date != nil:
+            s.dated[*row.Date] = append(s.dated[*row.Date], row)
+        case row.Weekday != nil:
+            s.weekly[time.Weekday(*row.Weekday)] = append(s.weekly[time.Weekday(*row.Weekday)], row)
+        }
+    }
+    return s
+}
+
+type span struct {
+    start, end time.Time
+}
+
+// spans returns the merged open periods that start between the day before t and the lookahead
+func (s *Schedule) spans(t time.Time) []span {
+    local := t.In(s.location)
+    var spans []span
+    for offset := -1; offset <= lookahead; offset++ {
+        day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, s.location)
+        rows, ok := s.dated[day.Format(dateLayout)]
+        if !ok {
+            rows = s.weekly[day.Weekday()]
+        }
+        for _, row := range rows {
+            if row.Closed {
+                continue
+            }
+            start := time.Date(day.Year(), day.Month(), day.Day(), 0, row.Opens, 0, 0, s.location)
+            end := time.Date(day.Year(), day.Month(), day.Day(), 0, row.Closes, 0, 0, s.location)
+            if row.Closes <= row.Opens {
+                end = time.Date(day.Year(), day.Month(), day.Day()+1, 0, row.Closes, 0, 0, s.location)
+            }
+            spans = append(spans, span{start, end})
+        }
+    }
+
+    sort.Slice(spans, func(i, j int) bool { return spans[i].start.Before(spans[j].start) })
+    merged := spans[:0]
+    for _, sp := range spans {
+        if n := len(merged); n > 0 && !sp.start.After(merged[n-1].end) {
+            if sp.end.After(merged[n-1].end) {
+                merged[n-1].end = sp.end
+            }
+            continue
+        }
+        merged = append(merged, sp)
+    }
+    return merged
+}
+
+// Status reports whether the restaurant is open at t and when that next changes.
+// The next change is nil when the restaurant neither opens nor closes within the lookahead.
+func (s *Schedule) Status(t time.Time) models.OpenStatus {
+    for _, sp := range s.spans(t) {
+        if t.Before(sp.start) {
+            next := sp.start
+            return models.OpenStatus{IsOpenNow: false, NextChangeAt: &next}
+        }
+        if t.Before(sp.end) {
+            status := models.OpenStatus{IsOpenNow: true}
+            // a span reaching the end of the lookahead means the restaurant is open around the clock
+            local := t.In(s.location)
+            if sp.end.Before(time.Date(local.Year(), local.Month(), local.Day()+lookahead, 0, 0, 0, 0, s.location)) {
+                next := sp.end
+                status.NextChangeAt = &next
+            }
+            return status
+        }
+    }
+    return models.OpenStatus{}
+}
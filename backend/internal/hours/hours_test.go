@@ -0,0 +1,220 @@
+package hours
+
+import (
+    "reflect"
+    "testing"
+    "time"
+
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+func TestParseClock(t *testing.T) {
+    tests := []struct {
+        value   string
+        want    int
+        wantErr bool
+    }{
+        {"00:00", 0, false},
+        {"09:30", 570, false},
+        {"23:59", 1439, false},
+        {"24:00", 0, false},
+        {"24:01", 0, true},
+        {"25:00", 0, true},
+        {"12:60", 0, true},
+        {"9:30", 0, true},
+        {"09:3", 0, true},
+        {"0930", 0, true},
+        {"-1:00", 0, true},
+        {"ab:cd", 0, true},
+        {"", 0, true},
+    }
+    for _, tt := range tests {
+        got, err := ParseClock(tt.value)
+        if (err != nil) != tt.wantErr || got != tt.want {
+            t.Errorf("ParseClock(%q) = %d, %v, want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+        }
+    }
+}
+
+func TestFormatClock(t *testing.T) {
+    tests := []struct {
+        minutes int
+        want    string
+    }{
+        {0, "00:00"},
+        {570, "09:30"},
+        {1439, "23:59"},
+    }
+    for _, tt := range tests {
+        if got := FormatClock(tt.minutes); got != tt.want {
+            t.Errorf("FormatClock(%d) = %s, want %s", tt.minutes, got, tt.want)
+        }
+    }
+}
+
+func TestValidTimeZoneWithoutDatabase(t *testing.T) {
+    // names Go rejects, or that only mean something to Go, never reach the database
+    for _, name := range []string{"", "Local", "Mars/Base"} {
+        if ok, err := ValidTimeZone(nil, name); ok || err != nil {
+            t.Errorf("ValidTimeZone(%q) = %v, %v, want false, nil", name, ok, err)
+        }
+    }
+}
+
+func TestRowsRejectsInvalidValues(t *testing.T) {
+    tests := []struct {
+        name     string
+        schedule models.OpeningHoursSchedule
+    }{
+        {"weekly opening", models.OpeningHoursSchedule{Weekly: []models.WeeklyHours{{Day: 1, Opens: "9:00", Closes: "17:00"}}}},
+        {"weekly closing", models.OpeningHoursSchedule{Weekly: []models.WeeklyHours{{Day: 1, Opens: "09:00", Closes: "17:75"}}}},
+        {"exception date", models.OpeningHoursSchedule{Exceptions: []models.HoursException{{Date: "2026-13-01", Closed: true}}}},
+        {"exception hours", models.OpeningHoursSchedule{Exceptions: []models.HoursException{{Date: "2026-12-25", Opens: "10:00", Closes: "noon"}}}},
+    }
+    for _, tt := range tests {
+        if _, err := Rows(1, tt.schedule); err == nil {
+            t.Errorf("%s: Rows accepted %+v", tt.name, tt.schedule)
+        }
+    }
+}
+
+func TestRowsViewRoundTrip(t *testing.T) {
+    schedule := models.OpeningHoursSchedule{
+        TimeZone: "Asia/Kolkata",
+        Weekly: []models.WeeklyHours{
+            {Day: 1, Opens: "09:00", Closes: "17:00"},
+            {Day: 5, Opens: "09:00", Closes: "17:00"},
+            {Day: 5, Opens: "20:00", Closes: "02:00"},
+        },
+        Exceptions: []models.HoursException{
+            {Date: "2026-10-21", Closed: true},
+            {Date: "2026-12-31", Opens: "18:00", Closes: "00:00"},
+        },
+    }
+    rows, err := Rows(7, schedule)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, row := range rows {
+        if row.RestaurantID != 7 {
+            t.Errorf("row %+v does not belong to restaurant 7", row)
+        }
+    }
+
+    // rows come back from the database in any order
+    reversed := make([]models.OpeningHours, len(rows))
+    for i, row := range rows {
+        reversed[len(rows)-1-i] = row
+    }
+    if got := View(schedule.TimeZone, reversed); !reflect.DeepEqual(got, schedule) {
+        t.Errorf("View(Rows(schedule)) = %+v, want %+v", got, schedule)
+    }
+}
+
+func TestStatus(t *testing.T) {
+    location, err := time.LoadLocation("Asia/Kolkata")
+    if err != nil {
+        t.Skip("time zone data is not available: ", err)
+    }
+    // Sunday 18 October 2026 to Saturday 24 October 2026
+    at := func(day, hour, minute int) time.Time {
+        return time.Date(2026, time.October, day, hour, minute, 0, 0, location)
+    }
+
+    rows, err := Rows(1, models.OpeningHoursSchedule{
+        TimeZone: "Asia/Kolkata",
+        Weekly: []models.WeeklyHours{
+            {Day: 1, Opens: "09:00", Closes: "17:00"},
+            {Day: 2, Opens: "09:00", Closes: "17:00"},
+            {Day: 3, Opens: "09:00", Closes: "17:00"},
+            {Day: 4, Opens: "09:00", Closes: "17:00"},
+            {Day: 4, Opens: "17:00", Closes: "22:00"},
+            {Day: 5, Opens: "09:00", Closes: "17:00"},
+            {Day: 5, Opens: "20:00", Closes: "02:00"},
+            {Day: 6, Opens: "10:00", Closes: "14:00"},
+        },
+        Exceptions: []models.HoursException{
+            {Date: "2026-10-21", Closed: true},
+            {Date: "2026-10-24", Opens: "12:00", Closes: "13:00"},
+        },
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    schedule := NewSchedule("Asia/Kolkata", rows)
+
+    tests := []struct {
+        name     string
+        t        time.Time
+        wantOpen bool
+        wantNext time.Time
+    }{
+        {"before opening", at(19, 8, 0), false, at(19, 9, 0)},
+        {"at opening", at(19, 9, 0), true, at(19, 17, 0)},
+        {"open", at(19, 10, 0), true, at(19, 17, 0)},
+        {"at closing", at(19, 17, 0), false, at(20, 9, 0)},
+        {"closed on the weekend", at(18, 12, 0), false, at(19, 9, 0)},
+        {"closed for a holiday", at(20, 18, 0), false, at(22, 9, 0)},
+        {"back to back hours", at(22, 16, 0), true, at(22, 22, 0)},
+        {"between two openings", at(23, 17, 30), false, at(23, 20, 0)},
+        {"before midnight", at(23, 23, 0), true, at(24, 2, 0)},
+        {"after midnight", at(24, 1, 0), true, at(24, 2, 0)},
+        {"exception hours replace the weekly ones", at(24, 10, 30), false, at(24, 12, 0)},
+        {"during exception hours", at(24, 12, 30), true, at(24, 13, 0)},
+        {"other time zone", at(19, 10, 0).UTC(), true, at(19, 17, 0)},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := schedule.Status(tt.t)
+            if got.IsOpenNow != tt.wantOpen {
+                t.Errorf("IsOpenNow = %v, want %v", got.IsOpenNow, tt.wantOpen)
+            }
+            if got.NextChangeAt == nil || !got.NextChangeAt.Equal(tt.wantNext) {
+                t.Errorf("NextChangeAt = %v, want %v", got.NextChangeAt, tt.wantNext)
+            }
+        })
+    }
+}
+
+func TestStatusWithoutChanges(t *testing.T) {
+    always := []models.WeeklyHours{}
+    for day := 0; day < 7; day++ {
+        always = append(always, models.WeeklyHours{Day: day, Opens: "00:00", Closes: "24:00"})
+    }
+    allDay, err := Rows(1, models.OpeningHoursSchedule{Weekly: always})
+    if err != nil {
+        t.Fatal(err)
+    }
+    now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+    tests := []struct {
+        name     string
+        timeZone string
+        rows     []models.OpeningHours
+        wantOpen bool
+    }{
+        {"no hours", "UTC", nil, false},
+        {"open around the clock", "UTC", allDay, true},
+        {"unknown time zone", "Mars/Base", allDay, true},
+    }
+    for _, tt := range tests {
+        got := NewSchedule(tt.timeZone, tt.rows).Status(now)
+        if got.IsOpenNow != tt.wantOpen || got.NextChangeAt != nil {
+            t.Errorf("%s: Status = %v, %v, want %v, nil", tt.name, got.IsOpenNow, got.NextChangeAt, tt.wantOpen)
+        }
+    }
+}
+
+func TestNewScheduleFallsBackToUTC(t *testing.T) {
+    rows, err := Rows(1, models.OpeningHoursSchedule{Weekly: []models.WeeklyHours{{Day: 1, Opens: "09:00", Closes: "17:00"}}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, timeZone := range []string{"", "Mars/Base"} {
+        got := NewSchedule(timeZone, rows).Status(time.Date(2026, time.October, 19, 8, 0, 0, 0, time.UTC))
+        want := time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+        if got.IsOpenNow || got.NextChangeAt == nil || !got.NextChangeAt.Equal(want) {
+            t.Errorf("NewSchedule(%q).Status = %v, %v, want false, %v", timeZone, got.IsOpenNow, got.NextChangeAt, want)
+        }
+    }
+}
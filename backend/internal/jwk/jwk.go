@@ -0,0 +1,132 @@
+package jwk
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/rsa"
+    "encoding/base64"
+    "fmt"
+    "math/big"
+)
+
+// Key is a public JSON Web Key (RFC 7517)
+type Key struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid,omitempty"`
+    Use string `json:"use,omitempty"`
+    Alg string `json:"alg,omitempty"`
+    // RSA
+    N string `json:"n,omitempty"`
+    E string `json:"e,omitempty"`
+    // EC and OKP
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+    Y   string `json:"y,omitempty"`
+}
+
+// Set is a JSON Web Key Set as served from a jwks_uri
+type Set struct {
+    Keys []Key `json:"keys"`
+}
+
+// Find returns the key with the given kid
+func (s Set) Find(kid string) (Key, bool) {
+    for _, key := range s.Keys {
+        if key.Kid == kid {
+            return key, true
+        }
+    }
+    return Key{}, false
+}
+
+// FromPublicKey encodes an RSA, EC or Ed25519 public key
+func FromPublicKey(public crypto.PublicKey) (Key, error) {
+    switch public := public.(type) {
+    case *rsa.PublicKey:
+        return Key{
+            Kty: "RSA",
+            N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+            E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+        }, nil
+    case *ecdsa.PublicKey:
+        size := (public.Curve.Params().BitSize + 7) / 8
+        return Key{
+            Kty: "EC",
+            Crv: public.Curve.Params().Name,
+            X:   base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size))),
+            Y:   base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size))),
+        }, nil
+    case ed25519.PublicKey:
+        return Key{
+            Kty: "OKP",
+            Crv: "Ed25519",
+            X:   base64.RawURLEncoding.EncodeToString(public),
+        }, nil
+    default:
+        return Key{}, fmt.Errorf("unsupported public key type %T", public)
+    }
+}
+
+// PublicKey decodes the key into an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+    switch k.Kty {
+    case "RSA":
+        n, err := decodeInt(k.N)
+        if err != nil {
+            return nil, err
+        }
+        e, err := decodeInt(k.E)
+        if err != nil {
+            return nil, err
+        }
+        return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+    case "EC":
+        var curve elliptic.Curve
+        switch k.Crv {
+        case "P-256":
+            curve = elliptic.P256()
+        case "P-384":
+            curve = elliptic.P384()
+        case "P-521":
+            curve = elliptic.P521()
+        default:
+            return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+        }
+        x, err := decodeInt(k.X)
+        if err != nil {
+            return nil, err
+        }
+        y, err := decodeInt(k.Y)
+        if err != nil {
+            return nil, err
+        }
+        if !curve.IsOnCurve(x, y) {
+            return nil, fmt.Errorf("invalid EC point")
+        }
+        return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+    case "OKP":
+        if k.Crv != "Ed25519" {
+            return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+        }
+        x, err := base64.RawURLEncoding.DecodeString(k.X)
+        if err != nil {
+            return nil, err
+        }
+        if len(x) != ed25519.PublicKeySize {
+            return nil, fmt.Errorf("invalid Ed25519 key")
+        }
+        return ed25519.PublicKey(x), nil
+    default:
+        return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+    }
+}
+
+func decodeInt(s string) (*big.Int, error) {
+    b, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil {
+        return nil, err
+    }
+    return new(big.Int).SetBytes(b), nil
+}
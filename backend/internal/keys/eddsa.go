@@ -0,0 +1,45 @@
+package keys
+
+import (
+    "crypto/ed25519"
+
+    "github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA implements Ed25519 signatures (RFC 8037), which jwt-go does not ship
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+    jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+        return SigningMethodEdDSA
+    })
+}
+
+func (m *signingMethodEdDSA) Alg() string {
+    return "EdDSA"
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+    privateKey, ok := key.(ed25519.PrivateKey)
+    if !ok {
+        return "", jwt.ErrInvalidKeyType
+    }
+    return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+    publicKey, ok := key.(ed25519.PublicKey)
+    if !ok {
+        return jwt.ErrInvalidKeyType
+    }
+    sig, err := jwt.DecodeSegment(signature)
+    if err != nil {
+        return err
+    }
+    if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+        return jwt.ErrSignatureInvalid
+    }
+    return nil
+}
@@ -0,0 +1,276 @@
+package keys
+
+import (
+    "crypto"
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "os"
+    "sync"
+    "time"
+
+    "github.com/dgrijalva/jwt-go"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/jwk"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "gorm.io/gorm"
+)
+
+const (
+    StatusActive  = "active"
+    StatusVerify  = "verify"
+    StatusRetired = "retired"
+)
+
+// Key is a parsed signing key
+type Key struct {
+    KID       string
+    Algorithm string
+    Private   crypto.Signer
+}
+
+var (
+    mu         sync.RWMutex
+    signing    *Key
+    verifying  = map[string]*Key{}
+    loadedAt   time.Time
+    reloadLock sync.Mutex
+)
+
+// Load reads the active and verify keys from the database, generating a first
+// RS256 key when none exists yet
+func Load() error {
+    var records []models.SigningKey
+    if err := db.DB.Where("status IN ?", []string{StatusActive, StatusVerify}).Order("created_at DESC").Find(&records).Error; err != nil {
+        return err
+    }
+
+    hasActive := false
+    for _, record := range records {
+        hasActive = hasActive || record.Status == StatusActive
+    }
+    if !hasActive {
+        record, err := Generate(db.DB, "RS256")
+        if err != nil {
+            return err
+        }
+        logger.Log.Infof("Generated signing key %s", record.KID)
+        records = append([]models.SigningKey{record}, records...)
+    }
+
+    var newSigning *Key
+    newVerifying := map[string]*Key{}
+    for _, record := range records {
+        key, err := parse(record)
+        if err != nil {
+            return fmt.Errorf("signing key %s: %w", record.KID, err)
+        }
+        newVerifying[key.KID] = key
+        // The newest active key signs
+        if record.Status == StatusActive && newSigning == nil {
+            newSigning = key
+        }
+    }
+
+    mu.Lock()
+    signing = newSigning
+    verifying = newVerifying
+    loadedAt = time.Now()
+    mu.Unlock()
+    return nil
+}
+
+// StartRefresh reloads the keys periodically so rotations made by other
+// instances or the keys CLI are picked up
+func StartRefresh(interval time.Duration) {
+    go func() {
+        for range time.Tick(interval) {
+            if err := Load(); err != nil {
+                logger.Log.Error("Error reloading signing keys: ", err)
+            }
+        }
+    }()
+}
+
+// Generate creates a new active key and demotes the previous active keys to verify only
+func Generate(tx *gorm.DB, algorithm string) (models.SigningKey, error) {
+    var private crypto.Signer
+    var err error
+    switch algorithm {
+    case "RS256":
+        private, err = rsa.GenerateKey(rand.Reader, 2048)
+    case "EdDSA":
+        _, private, err = ed25519.GenerateKey(rand.Reader)
+    default:
+        return models.SigningKey{}, fmt.Errorf("unsupported algorithm %q", algorithm)
+    }
+    if err != nil {
+        return models.SigningKey{}, err
+    }
+
+    der, err := x509.MarshalPKCS8PrivateKey(private)
+    if err != nil {
+        return models.SigningKey{}, err
+    }
+    kid, err := randomKID()
+    if err != nil {
+        return models.SigningKey{}, err
+    }
+    record := models.SigningKey{
+        KID:        kid,
+        Algorithm:  algorithm,
+        PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+        Status:     StatusActive,
+    }
+
+    err = tx.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&models.SigningKey{}).Where("status = ?", StatusActive).Update("status", StatusVerify).Error; err != nil {
+            return err
+        }
+        return tx.Create(&record).Error
+    })
+    return record, err
+}
+
+// Retire stops a key from verifying tokens. The active key cannot be retired
+// until a newer key has been generated.
+func Retire(tx *gorm.DB, kid string) error {
+    var record models.SigningKey
+    if err := tx.Where("kid = ?", kid).First(&record).Error; err != nil {
+        return err
+    }
+    if record.Status == StatusActive {
+        return errors.New("cannot retire the active key, generate a new key first")
+    }
+    return tx.Model(&record).Updates(map[string]interface{}{"status": StatusRetired, "retired_at": time.Now()}).Error
+}
+
+// Sign signs the claims with the active key
+func Sign(claims jwt.MapClaims) (string, error) {
+    mu.RLock()
+    key := signing
+    mu.RUnlock()
+    if key == nil {
+        return "", errors.New("no active signing key")
+    }
+
+    token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+    token.Header["kid"] = key.KID
+    return token.SignedString(key.Private)
+}
+
+// legacyTokenLifetime is the longest lifetime HS256 tokens were ever issued with
+const legacyTokenLifetime = 72 * time.Hour
+
+// Keyfunc resolves the verification key for a token by its kid header.
+// Legacy HS256 tokens without a kid are only accepted when allowed by legacySecret.
+func Keyfunc(token *jwt.Token) (interface{}, error) {
+    kid, _ := token.Header["kid"].(string)
+    if kid == "" {
+        return legacySecret(token)
+    }
+
+    key := lookup(kid)
+    if key == nil {
+        return nil, fmt.Errorf("unknown signing key %q", kid)
+    }
+    // Never let the token choose a different algorithm than the key was made for
+    if token.Method.Alg() != key.Algorithm {
+        return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+    }
+    return key.Private.Public(), nil
+}
+
+// legacySecret returns JWT_SECRET for HS256 tokens issued before the switch to asymmetric keys.
+// It requires JWT_ACCEPT_HS256=true and JWT_HS256_CUTOVER, the RFC 3339 time the switch was
+// deployed, and only accepts tokens that were issued before the cutover and expire within
+// legacyTokenLifetime of it. Anyone still holding the secret can therefore not forge tokens
+// that outlive the transition.
+func legacySecret(token *jwt.Token) (interface{}, error) {
+    secret := os.Getenv("JWT_SECRET")
+    if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || secret == "" || !configs.GetBool("JWT_ACCEPT_HS256", false) {
+        return nil, errors.New("token has no key id")
+    }
+    cutover, err := time.Parse(time.RFC3339, os.Getenv("JWT_HS256_CUTOVER"))
+    if err != nil {
+        return nil, errors.New("legacy tokens are accepted but JWT_HS256_CUTOVER is not a valid time")
+    }
+    claims, _ := token.Claims.(jwt.MapClaims)
+    exp, ok := claims["exp"].(float64)
+    if !ok || time.Unix(int64(exp), 0).After(cutover.Add(legacyTokenLifetime)) {
+        return nil, errors.New("legacy token expires after the end of the transition")
+    }
+    if iat, ok := claims["iat"].(float64); ok && time.Unix(int64(iat), 0).After(cutover) {
+        return nil, errors.New("legacy token was issued after the cutover")
+    }
+    return []byte(secret), nil
+}
+
+// JWKS returns the public keys that currently verify tokens
+func JWKS() jwk.Set {
+    mu.RLock()
+    defer mu.RUnlock()
+    set := jwk.Set{Keys: []jwk.Key{}}
+    for _, key := range verifying {
+        public, err := jwk.FromPublicKey(key.Private.Public())
+        if err != nil {
+            continue
+        }
+        public.Kid = key.KID
+        public.Alg = key.Algorithm
+        public.Use = "sig"
+        set.Keys = append(set.Keys, public)
+    }
+    return set
+}
+
+// lookup finds a verification key, reloading once if the kid is unknown
+// because another instance may have rotated keys since the last refresh
+func lookup(kid string) *Key {
+    mu.RLock()
+    key := verifying[kid]
+    stale := time.Since(loadedAt) > 10*time.Second
+    mu.RUnlock()
+    if key != nil || !stale {
+        return key
+    }
+
+    reloadLock.Lock()
+    defer reloadLock.Unlock()
+    if err := Load(); err != nil {
+        logger.Log.Error("Error reloading signing keys: ", err)
+    }
+    mu.RLock()
+    defer mu.RUnlock()
+    return verifying[kid]
+}
+
+func parse(record models.SigningKey) (*Key, error) {
+    block, _ := pem.Decode([]byte(record.PrivateKey))
+    if block == nil {
+        return nil, errors.New("invalid PEM")
+    }
+    parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+    if err != nil {
+        return nil, err
+    }
+    private, ok := parsed.(crypto.Signer)
+    if !ok {
+        return nil, errors.New("unsupported private key type")
+    }
+    return &Key{KID: record.KID, Algorithm: record.Algorithm, Private: private}, nil
+}
+
+func randomKID() (string, error) {
+    b := make([]byte, 12)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("%x", b), nil
+}
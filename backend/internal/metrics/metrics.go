@@ -0,0 +1,29 @@
+package metrics
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// Application metrics, exposed on /metrics next to the ginprometheus request metrics
+
+var LoginFailures = prometheus.NewCounterVec(
+    prometheus.CounterOpts{
+        Namespace: "foodiebaba",
+        Name:      "login_failures_total",
+        Help:      "Failed login attempts by reason (invalid_credentials, invalid_mfa, throttled).",
+    },
+    []string{"reason"},
+)
+
+var LoginLockouts = prometheus.NewCounterVec(
+    prometheus.CounterOpts{
+        Namespace: "foodiebaba",
+        Name:      "login_lockouts_total",
+        Help:      "Temporary lockouts triggered by repeated login failures, by scope (account, ip).",
+    },
+    []string{"scope"},
+)
+
+func init() {
+    prometheus.MustRegister(LoginFailures, LoginLockouts)
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+// setActiveUser loads the authenticated user and rejects suspended or banned
+// accounts even while their token is still valid. The role comes from the
+// database so role changes apply immediately.
+func setActiveUser(c *gin.Context, userID uint) bool {
+    var user models.User
+    if err := db.DB.Select("id", "role", "suspended_until", "banned_at").First(&user, userID).Error; err != nil {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+        return false
+    }
+    switch user.AccountStatus() {
+    case models.AccountBanned:
+        c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This account has been banned", "code": "account_banned"})
+        return false
+    case models.AccountSuspended:
+        c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+            "error": "This account is suspended",
+            "code":  "account_suspended",
+            "until": user.SuspendedUntil,
+        })
+        return false
+    }
+    c.Set("userID", user.ID)
+    c.Set("userRole", user.Role)
+    return true
+}
@@ -0,0 +1,116 @@
+package middlewares
+
+import (
+    "crypto/subtle"
+    "net/http"
+    "time"
+
+    "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+    "github.com/pp00x/foodiebaba/internal/utils"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+)
+
+// apiKeyRoutes are the protected routes API keys may call, with the scope a scoped key
+// needs; admin routes need their permission. Keys are refused everywhere else, so account,
+// session, two-factor and API key routes, and the management of roles, bans and
+// suspensions, can only be used with a login.
+var apiKeyRoutes = map[string]string{
+    "POST /restaurants":                   rbac.ScopeListingsWrite,
+    "PATCH /restaurants/:id":              rbac.ScopeListingsWrite,
+    "PUT /restaurants/:id/tags":           rbac.ScopeListingsWrite,
+    "PUT /restaurants/:id/hours":          rbac.ScopeListingsWrite,
+    "PUT /restaurants/:id/attributes":     rbac.ScopeListingsWrite,
+    "POST /restaurants/:id/suggestions":   rbac.ScopeListingsWrite,
+    "POST /restaurants/:id/photos":        rbac.ScopeListingsWrite,
+    "POST /restaurants/:id/menus":         rbac.ScopeListingsWrite,
+    "PUT /menus/:id":                      rbac.ScopeListingsWrite,
+    "DELETE /menus/:id":                   rbac.ScopeListingsWrite,
+    "POST /menus/:id/sections":            rbac.ScopeListingsWrite,
+    "PUT /menu-sections/:id":              rbac.ScopeListingsWrite,
+    "DELETE /menu-sections/:id":           rbac.ScopeListingsWrite,
+    "POST /menu-sections/:id/items":       rbac.ScopeListingsWrite,
+    "PUT /menu-items/:id":                 rbac.ScopeListingsWrite,
+    "DELETE /menu-items/:id":              rbac.ScopeListingsWrite,
+    "PUT /menu-items/:id/photo":           rbac.ScopeListingsWrite,
+    "POST /reviews":                       rbac.ScopeReviewsWrite,
+
+    "GET /admin/restaurants/pending":                          rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/:id/approve":                      rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/:id/reject":                       rbac.RestaurantsApprove,
+    "GET /admin/restaurants/flagged":                          rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/:id/location":                     rbac.RestaurantsEdit,
+    "POST /admin/restaurants/:id/revisions/:revision/rollback": rbac.RestaurantsApprove,
+    "GET /admin/restaurants/edits":                            rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/edits/:id/approve":                rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/edits/:id/reject":                 rbac.RestaurantsApprove,
+    "GET /admin/restaurants/suggestions":                      rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/suggestions/:id/approve":          rbac.RestaurantsApprove,
+    "PUT /admin/restaurants/suggestions/:id/reject":           rbac.RestaurantsApprove,
+    "POST /admin/tags":                                        rbac.TagsManage,
+    "PATCH /admin/tags/:id":                                   rbac.TagsManage,
+    "DELETE /admin/tags/:id":                                  rbac.TagsManage,
+    "DELETE /admin/reviews/:id":                               rbac.ReviewsDelete,
+    "GET /admin/users":                                        rbac.UsersView,
+    "GET /admin/users/:id":                                    rbac.UsersView,
+}
+
+// apiKeyAllowed reports whether a key with the scopes may call the current route,
+// responding with an error when it may not
+func apiKeyAllowed(c *gin.Context, scopes []string) bool {
+    scope, ok := apiKeyRoutes[c.Request.Method+" "+c.FullPath()]
+    if !ok {
+        c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys cannot be used for this route"})
+        return false
+    }
+    if len(scopes) > 0 && !contains(scopes, scope) {
+        c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope does not allow this", "scope": scope})
+        return false
+    }
+    return true
+}
+
+// authenticateAPIKey sets the same context values as a JWT for a valid personal API key
+func authenticateAPIKey(c *gin.Context, key string, public bool) {
+    prefix, ok := utils.ParseAPIKey(key)
+    if !ok {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+        return
+    }
+
+    var apiKey models.APIKey
+    if err := db.DB.Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+        return
+    }
+    if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(utils.HashToken(key))) != 1 || apiKey.RevokedAt != nil {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+        return
+    }
+    if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+        c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key expired"})
+        return
+    }
+
+    if !setActiveUser(c, apiKey.UserID) {
+        return
+    }
+
+    // Only record usage once a minute to keep writes down for busy scripts
+    if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) > time.Minute {
+        if err := db.DB.Model(&apiKey).UpdateColumn("last_used_at", time.Now()).Error; err != nil {
+            logger.Log.Error("Error updating API key usage: ", err)
+        }
+    }
+
+    // Keys are long-lived secrets, so they never count as a two-factor login
+    c.Set("userMFA", false)
+    c.Set("apiKeyID", apiKey.ID)
+    c.Set("apiKeyScopes", apiKey.Scopes)
+    if !public && !apiKeyAllowed(c, apiKey.Scopes) {
+        return
+    }
+    c.Next()
+}
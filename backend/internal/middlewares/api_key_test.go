@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+)
+
+func TestAPIKeyAllowed(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    tests := []struct {
+        name   string
+        method string
+        route  string
+        path   string
+        scopes []string
+        want   int
+    }{
+        {"listing without scopes", http.MethodPost, "/restaurants", "/restaurants", nil, http.StatusOK},
+        {"listing with its scope", http.MethodPatch, "/restaurants/:id", "/restaurants/1", []string{rbac.ScopeListingsWrite}, http.StatusOK},
+        {"listing with another scope", http.MethodPost, "/restaurants", "/restaurants", []string{rbac.ScopeReviewsWrite}, http.StatusForbidden},
+        {"review with its scope", http.MethodPost, "/reviews", "/reviews", []string{rbac.ScopeReviewsWrite}, http.StatusOK},
+        {"admin route without scopes", http.MethodGet, "/admin/users", "/admin/users", nil, http.StatusOK},
+        {"admin route with its permission", http.MethodPut, "/admin/restaurants/:id/approve", "/admin/restaurants/1/approve", []string{rbac.RestaurantsApprove}, http.StatusOK},
+        {"admin route with a user scope", http.MethodPut, "/admin/restaurants/:id/approve", "/admin/restaurants/1/approve", []string{rbac.ScopeListingsWrite}, http.StatusForbidden},
+        {"tag management with another permission", http.MethodPost, "/admin/tags", "/admin/tags", []string{rbac.RestaurantsEdit}, http.StatusForbidden},
+        {"account", http.MethodGet, "/me", "/me", nil, http.StatusForbidden},
+        {"api keys", http.MethodPost, "/me/api-keys", "/me/api-keys", nil, http.StatusForbidden},
+        {"ban", http.MethodPost, "/admin/users/:id/ban", "/admin/users/1/ban", nil, http.StatusForbidden},
+        {"role change", http.MethodPut, "/admin/users/:id/role", "/admin/users/1/role", nil, http.StatusForbidden},
+        {"role change with every scope", http.MethodPut, "/admin/users/:id/role", "/admin/users/1/role", []string{rbac.UsersRoles, rbac.UsersBan}, http.StatusForbidden},
+        {"unlisted method", http.MethodDelete, "/restaurants/:id", "/restaurants/1", nil, http.StatusForbidden},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            r := gin.New()
+            r.Handle(tt.method, tt.route, func(c *gin.Context) {
+                if apiKeyAllowed(c, tt.scopes) {
+                    c.Status(http.StatusOK)
+                }
+            })
+            w := httptest.NewRecorder()
+            r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+            if w.Code != tt.want {
+                t.Errorf("%s %s with scopes %v = %d, want %d", tt.method, tt.path, tt.scopes, w.Code, tt.want)
+            }
+        })
+    }
+}
+
+func TestAPIKeyRoutesUseKnownScopes(t *testing.T) {
+    for route, scope := range apiKeyRoutes {
+        if !rbac.ValidScope(rbac.RoleOwner, scope) {
+            t.Errorf("%s needs %q, which no API key can be given", route, scope)
+        }
+    }
+}
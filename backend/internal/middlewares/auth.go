@@ -2,31 +2,67 @@ package middlewares
 
 import (
     "net/http"
-    "os"
     "strings"
 
-    "github.com/dgrijalva/jwt-go"
     "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/internal/utils"
 )
 
 func JWTAuth() gin.HandlerFunc {
+    return authenticate(false)
+}
+
+// authenticate accepts an access token or an API key. API keys are only accepted on the
+// routes in apiKeyRoutes, unless public is set for routes that anyone can call anyway.
+func authenticate(public bool) gin.HandlerFunc {
     return func(c *gin.Context) {
+        if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+            authenticateAPIKey(c, apiKey, public)
+            return
+        }
         authHeader := c.GetHeader("Authorization")
         if authHeader == "" {
             c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
             return
         }
         tokenString := strings.TrimSpace(strings.Replace(authHeader, "Bearer", "", 1))
-        token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-            return []byte(os.Getenv("JWT_SECRET")), nil
-        })
-        if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-            c.Set("userID", uint(claims["user_id"].(float64)))
-            c.Set("userRole", claims["role"].(string))
-        } else {
+        if _, ok := utils.ParseAPIKey(tokenString); ok {
+            authenticateAPIKey(c, tokenString, public)
+            return
+        }
+        claims, err := utils.ParseToken(tokenString)
+        if err != nil {
             c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
             return
         }
+        // MFA challenge tokens only unlock the second login step
+        if claims["type"] == utils.TokenTypeMFA {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Two-factor authentication not completed"})
+            return
+        }
+        userID, ok := claims["user_id"].(float64)
+        if !ok {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+            return
+        }
+        mfa, _ := claims["mfa"].(bool)
+        c.Set("userMFA", mfa)
+        if !setActiveUser(c, uint(userID)) {
+            return
+        }
         c.Next()
     }
-}
\ No newline at end of file
+}
+
+// OptionalJWTAuth authenticates the request like JWTAuth when it carries credentials
+// and lets anonymous requests through without a user
+func OptionalJWTAuth() gin.HandlerFunc {
+    authenticate := authenticate(true)
+    return func(c *gin.Context) {
+        if c.GetHeader("Authorization") == "" && c.GetHeader("X-API-Key") == "" {
+            c.Next()
+            return
+        }
+        authenticate(c)
+    }
+}
@@ -0,0 +1,56 @@
+package middlewares
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+)
+
+// RequirePermission only lets through users whose role grants the permission
+func RequirePermission(permission string) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        role := c.GetString("userRole")
+        if !rbac.Can(role, permission) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to do this", "permission": permission})
+            return
+        }
+        // API keys can be limited to a subset of their owner's permissions
+        if scopes := c.GetStringSlice("apiKeyScopes"); len(scopes) > 0 && !contains(scopes, permission) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope does not allow this", "permission": permission})
+            return
+        }
+        // REQUIRE_ADMIN_2FA makes admins log in with a second factor
+        if rbac.IsPrivileged(role) && configs.GetBool("REQUIRE_ADMIN_2FA", false) && !c.GetBool("userMFA") {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+                "error": "Admins must log in with two-factor authentication",
+                "code":  "mfa_required",
+            })
+            return
+        }
+        c.Next()
+    }
+}
+
+// HasPermission reports whether the authenticated request may use the permission,
+// for handlers that only need it for part of what they do
+func HasPermission(c *gin.Context, permission string) bool {
+    role := c.GetString("userRole")
+    if !rbac.Can(role, permission) {
+        return false
+    }
+    if scopes := c.GetStringSlice("apiKeyScopes"); len(scopes) > 0 && !contains(scopes, permission) {
+        return false
+    }
+    return !rbac.IsPrivileged(role) || !configs.GetBool("REQUIRE_ADMIN_2FA", false) || c.GetBool("userMFA")
+}
+
+func contains(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
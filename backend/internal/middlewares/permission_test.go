@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/internal/rbac"
+)
+
+func TestRequirePermission(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    tests := []struct {
+        name       string
+        role       string
+        permission string
+        scopes     []string
+        require2FA string
+        mfa        bool
+        want       int
+    }{
+        {"user", rbac.RoleUser, rbac.RestaurantsEdit, nil, "", false, http.StatusForbidden},
+        {"editor edits", rbac.RoleEditor, rbac.RestaurantsEdit, nil, "", false, http.StatusOK},
+        {"editor approves", rbac.RoleEditor, rbac.RestaurantsApprove, nil, "", false, http.StatusForbidden},
+        {"moderator approves", rbac.RoleModerator, rbac.RestaurantsApprove, nil, "", false, http.StatusOK},
+        {"moderator bans", rbac.RoleModerator, rbac.UsersBan, nil, "", false, http.StatusForbidden},
+        {"admin manages admins", rbac.RoleAdmin, rbac.AdminsManage, nil, "", false, http.StatusForbidden},
+        {"owner manages admins", rbac.RoleOwner, rbac.AdminsManage, nil, "", false, http.StatusOK},
+        {"unknown role", "superuser", rbac.RestaurantsEdit, nil, "", false, http.StatusForbidden},
+        {"key scoped to the permission", rbac.RoleModerator, rbac.RestaurantsApprove, []string{rbac.RestaurantsApprove}, "", false, http.StatusOK},
+        {"key scoped elsewhere", rbac.RoleModerator, rbac.RestaurantsApprove, []string{rbac.ReviewsDelete}, "", false, http.StatusForbidden},
+        {"scope beyond the role", rbac.RoleEditor, rbac.RestaurantsApprove, []string{rbac.RestaurantsApprove}, "", false, http.StatusForbidden},
+        {"admin without 2FA required", rbac.RoleAdmin, rbac.UsersBan, nil, "false", false, http.StatusOK},
+        {"admin without second factor", rbac.RoleAdmin, rbac.UsersBan, nil, "true", false, http.StatusForbidden},
+        {"admin with second factor", rbac.RoleAdmin, rbac.UsersBan, nil, "true", true, http.StatusOK},
+        {"owner without second factor", rbac.RoleOwner, rbac.AdminsManage, nil, "1", false, http.StatusForbidden},
+        {"moderator is not required to use 2FA", rbac.RoleModerator, rbac.RestaurantsApprove, nil, "true", false, http.StatusOK},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("REQUIRE_ADMIN_2FA", tt.require2FA)
+
+            var has bool
+            r := gin.New()
+            r.GET("/", func(c *gin.Context) {
+                c.Set("userRole", tt.role)
+                c.Set("userMFA", tt.mfa)
+                if tt.scopes != nil {
+                    c.Set("apiKeyScopes", tt.scopes)
+                }
+                has = HasPermission(c, tt.permission)
+                c.Next()
+            }, RequirePermission(tt.permission), func(c *gin.Context) {
+                c.Status(http.StatusOK)
+            })
+            w := httptest.NewRecorder()
+            r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+            if w.Code != tt.want {
+                t.Errorf("RequirePermission(%q) for %s = %d, want %d", tt.permission, tt.role, w.Code, tt.want)
+            }
+            if has != (tt.want == http.StatusOK) {
+                t.Errorf("HasPermission(%q) for %s = %v, disagrees with RequirePermission", tt.permission, tt.role, has)
+            }
+        })
+    }
+}
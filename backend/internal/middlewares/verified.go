@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+    "net/http"
+
+    "github.com/gin-gonic/gin"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+// VerifiedEmailOnly blocks users that have not confirmed their email address yet
+func VerifiedEmailOnly() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        var user models.User
+        if err := db.DB.Select("id", "email_verified").First(&user, c.GetUint("userID")).Error; err != nil {
+            c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+            return
+        }
+        if !user.EmailVerified {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+                "error": "Please verify your email address before posting content",
+                "code":  "email_not_verified",
+            })
+            return
+        }
+        c.Next()
+    }
+}
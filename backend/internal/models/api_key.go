@@ -0,0 +1,27 @@
+package models
+
+import (
+    "time"
+)
+
+// APIKey lets scripts authenticate as the user who owns it. Only the hash of the
+// secret is stored; Prefix identifies the key in logs and listings.
+type APIKey struct {
+    ID         uint       `gorm:"primaryKey" json:"id"`
+    CreatedAt  time.Time  `json:"created_at"`
+    UpdatedAt  time.Time  `json:"updated_at"`
+    UserID     uint       `gorm:"index" json:"-"`
+    Name       string     `gorm:"size:100" json:"name"`
+    Prefix     string     `gorm:"size:16;uniqueIndex" json:"prefix"`
+    KeyHash    string     `gorm:"size:64" json:"-"`
+    Scopes     []string   `gorm:"serializer:json" json:"scopes"` // permissions and scopes the key is limited to, empty means no limit
+    ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+    LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+    RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateAPIKeyInput struct {
+    Name      string     `json:"name" validate:"required,max=100"`
+    Scopes    []string   `json:"scopes"`
+    ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// DietaryOptions are the diets a restaurant caters for
+type DietaryOptions struct {
+    Vegetarian bool `gorm:"default:false" json:"vegetarian"`
+    Vegan      bool `gorm:"default:false" json:"vegan"`
+    Halal      bool `gorm:"default:false" json:"halal"`
+    GlutenFree bool `gorm:"default:false" json:"gluten_free"`
+}
+
+// Amenities are the facilities and services a restaurant offers
+type Amenities struct {
+    OutdoorSeating       bool `gorm:"default:false" json:"outdoor_seating"`
+    WheelchairAccessible bool `gorm:"default:false" json:"wheelchair_accessible"`
+    Parking              bool `gorm:"default:false" json:"parking"`
+    WiFi                 bool `gorm:"column:wifi;default:false" json:"wifi"`
+    Delivery             bool `gorm:"default:false" json:"delivery"`
+    Takeaway             bool `gorm:"default:false" json:"takeaway"`
+}
+
+// DietaryColumns maps the dietary filter names accepted by the API to their columns
+var DietaryColumns = map[string]string{
+    "vegetarian":  "dietary_vegetarian",
+    "vegan":       "dietary_vegan",
+    "halal":       "dietary_halal",
+    "gluten_free": "dietary_gluten_free",
+}
+
+// AmenityColumns maps the amenity filter names accepted by the API to their columns
+var AmenityColumns = map[string]string{
+    "outdoor_seating":       "amenity_outdoor_seating",
+    "wheelchair_accessible": "amenity_wheelchair_accessible",
+    "parking":               "amenity_parking",
+    "wifi":                  "amenity_wifi",
+    "delivery":              "amenity_delivery",
+    "takeaway":              "amenity_takeaway",
+}
+
+type SetAttributesInput struct {
+    PriceLevel *int           `json:"price_level" validate:"omitempty,min=1,max=4"`
+    Dietary    DietaryOptions `json:"dietary"`
+    Amenities  Amenities      `json:"amenities"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+    "time"
+)
+
+// UserIdentity links a user to an account at an external OpenID Connect provider
+type UserIdentity struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+    UserID    uint      `gorm:"index" json:"user_id"`
+    Provider  string    `gorm:"size:50;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+    Subject   string    `gorm:"size:255;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+    Email     string    `gorm:"size:100" json:"email"`
+}
+
+// OAuthState holds the state, nonce and PKCE verifier of an OpenID Connect login in progress
+type OAuthState struct {
+    ID           uint      `gorm:"primaryKey"`
+    CreatedAt    time.Time
+    StateHash    string    `gorm:"size:64;uniqueIndex"`
+    Provider     string    `gorm:"size:50"`
+    Nonce        string    `gorm:"size:100"`
+    CodeVerifier string    `gorm:"size:100"`
+    ExpiresAt    time.Time `gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+    "time"
+)
+
+// LoginThrottle tracks recent failed logins for an account ("account:<email>") or a client IP ("ip:<address>")
+type LoginThrottle struct {
+    Key           string     `gorm:"primaryKey;size:255" json:"key"`
+    UpdatedAt     time.Time  `json:"updated_at"`
+    Failures      int        `json:"failures"`
+    LastFailureAt time.Time  `json:"last_failure_at"`
+    Lockouts      int        `json:"lockouts"` // consecutive lockouts, each one lasts longer
+    LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+    "gorm.io/gorm"
+    "time"
+)
+
+// Menu is one of a restaurant's menus, such as "Lunch" or "Drinks"
+type Menu struct {
+    ID           uint           `gorm:"primaryKey" json:"id"`
+    CreatedAt    time.Time      `json:"created_at"`
+    UpdatedAt    time.Time      `json:"updated_at"`
+    DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+    RestaurantID uint           `gorm:"index" json:"restaurant_id"`
+    Name         string         `gorm:"size:100" json:"name"`
+    Description  string         `json:"description"`
+    Position     int            `json:"position"` // menus, sections and items are listed by ascending position
+    Sections     []MenuSection  `json:"sections"`
+}
+
+// MenuSection groups the items of a menu, such as "Starters"
+type MenuSection struct {
+    ID          uint           `gorm:"primaryKey" json:"id"`
+    CreatedAt   time.Time      `json:"created_at"`
+    UpdatedAt   time.Time      `json:"updated_at"`
+    DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+    MenuID      uint           `gorm:"index" json:"menu_id"`
+    Name        string         `gorm:"size:100" json:"name"`
+    Description string         `json:"description"`
+    Position    int            `json:"position"`
+    Items       []MenuItem     `gorm:"foreignKey:SectionID" json:"items"`
+}
+
+// MenuItem is a dish or drink. RestaurantID is copied from its menu so dishes can be searched without joins.
+type MenuItem struct {
+    ID           uint           `gorm:"primaryKey" json:"id"`
+    CreatedAt    time.Time      `json:"created_at"`
+    UpdatedAt    time.Time      `json:"updated_at"`
+    DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+    SectionID    uint           `gorm:"index" json:"section_id"`
+    RestaurantID uint           `gorm:"index" json:"restaurant_id"`
+    Name         string         `gorm:"size:150;index" json:"name"`
+    Description  string         `json:"description"`
+    Price        float64        `gorm:"type:numeric(10,2)" json:"price"`
+    Currency     string         `gorm:"size:3" json:"currency"` // ISO 4217 code such as "INR"
+    Dietary      DietaryOptions `gorm:"embedded;embeddedPrefix:dietary_" json:"dietary"`
+    PhotoURL     string         `gorm:"size:255" json:"photo_url"`
+    Position     int            `json:"position"`
+}
+
+type MenuInput struct {
+    Name        string `json:"name" validate:"required,max=100"`
+    Description string `json:"description" validate:"max=1000"`
+    Position    int    `json:"position"`
+}
+
+type MenuSectionInput struct {
+    Name        string `json:"name" validate:"required,max=100"`
+    Description string `json:"description" validate:"max=1000"`
+    Position    int    `json:"position"`
+}
+
+type MenuItemInput struct {
+    Name        string         `json:"name" validate:"required,max=150"`
+    Description string         `json:"description" validate:"max=1000"`
+    Price       *float64       `json:"price" validate:"required,min=0,max=99999999"`
+    Currency    string         `json:"currency" validate:"required,len=3,uppercase"`
+    Dietary     DietaryOptions `json:"dietary"`
+    Position    int            `json:"position"`
+}
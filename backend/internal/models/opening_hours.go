@@ -0,0 +1,47 @@
+package models
+
+import (
+    "time"
+)
+
+// OpeningHours is one opening interval of a restaurant, in minutes after local midnight.
+// Weekly rows set Weekday (0 is Sunday); exception rows set Date instead and replace the
+// weekly hours on that date. An interval that closes at or before it opens runs past
+// midnight. A Closed exception row marks the whole date as closed.
+type OpeningHours struct {
+    ID           uint    `gorm:"primaryKey" json:"-"`
+    RestaurantID uint    `gorm:"index" json:"-"`
+    Weekday      *int    `json:"weekday,omitempty"`
+    Date         *string `gorm:"size:10;index" json:"date,omitempty"` // YYYY-MM-DD in the restaurant's time zone
+    Opens        int     `json:"opens"`
+    Closes       int     `json:"closes"`
+    Closed       bool    `json:"closed"`
+}
+
+// OpeningHoursSchedule is how opening hours are edited and shown. Times are "HH:MM".
+type OpeningHoursSchedule struct {
+    TimeZone   string           `json:"time_zone" validate:"required"` // IANA name such as "Asia/Kolkata"
+    Weekly     []WeeklyHours    `json:"weekly" validate:"dive"`
+    Exceptions []HoursException `json:"exceptions" validate:"dive"`
+}
+
+type WeeklyHours struct {
+    Day    int    `json:"day" validate:"min=0,max=6"` // 0 is Sunday
+    Opens  string `json:"opens" validate:"required"`
+    Closes string `json:"closes" validate:"required"`
+}
+
+// HoursException replaces the weekly hours on a date such as a holiday.
+// Dates listed with closed set have no hours at all.
+type HoursException struct {
+    Date   string `json:"date" validate:"required"`
+    Closed bool   `json:"closed"`
+    Opens  string `json:"opens,omitempty" validate:"required_without=Closed"`
+    Closes string `json:"closes,omitempty" validate:"required_without=Closed"`
+}
+
+// OpenStatus is computed from the opening hours at the time of the request
+type OpenStatus struct {
+    IsOpenNow    bool       `json:"is_open_now"`
+    NextChangeAt *time.Time `json:"next_change_at"` // when the restaurant next opens or closes, null if it never does in the coming weeks
+}
@@ -12,4 +12,5 @@ type Photo struct {
     DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
     URL         string         `gorm:"size:255" json:"url"`
     RestaurantID uint          `json:"restaurant_id"`
+    UploadedByID uint          `gorm:"index" json:"uploaded_by"`
 }
\ No newline at end of file
@@ -0,0 +1,38 @@
+package models
+
+import (
+    "time"
+)
+
+// PublicProfile is what anyone can see about a user. It never includes the
+// email, role, password or submissions that are not approved.
+type PublicProfile struct {
+    Username         string             `json:"username"`
+    DisplayName      string             `json:"display_name"`
+    Bio              string             `json:"bio"`
+    AvatarURL        string             `json:"avatar_url"`
+    HomeCity         string             `json:"home_city"`
+    JoinedAt         time.Time          `json:"joined_at"`
+    Reputation       int                `json:"reputation"`
+    ApprovedListings int64              `json:"approved_listings"`
+    ReviewCount      int64              `json:"review_count"`
+    Reviews          []PublicReview     `json:"reviews"`
+    Restaurants      []PublicRestaurant `json:"restaurants"`
+}
+
+type PublicReview struct {
+    ID             uint      `json:"id"`
+    CreatedAt      time.Time `json:"created_at"`
+    Rating         int       `json:"rating"`
+    Comment        string    `json:"comment"`
+    RestaurantID   uint      `json:"restaurant_id"`
+    RestaurantName string    `json:"restaurant_name"`
+}
+
+type PublicRestaurant struct {
+    ID        uint      `json:"id"`
+    CreatedAt time.Time `json:"created_at"`
+    Name      string    `json:"name"`
+    Address   string    `json:"address"`
+    Category  string    `json:"category"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import (
+    "time"
+)
+
+// RecoveryCode is a single-use backup code for two-factor authentication
+type RecoveryCode struct {
+    ID        uint       `gorm:"primaryKey" json:"id"`
+    CreatedAt time.Time  `json:"created_at"`
+    UserID    uint       `gorm:"index" json:"user_id"`
+    CodeHash  string     `gorm:"size:64;index" json:"-"`
+    UsedAt    *time.Time `json:"used_at,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+    "time"
+)
+
+// RefreshToken is a single-use token that can be exchanged for a new access token.
+// Tokens issued from the same login share a FamilyID so the whole chain can be revoked at once.
+type RefreshToken struct {
+    ID        uint       `gorm:"primaryKey" json:"id"`
+    CreatedAt time.Time  `json:"created_at"`
+    UpdatedAt time.Time  `json:"updated_at"`
+    UserID    uint       `gorm:"index" json:"user_id"`
+    FamilyID  string     `gorm:"size:64;index" json:"family_id"`
+    TokenHash string     `gorm:"size:64;uniqueIndex" json:"-"`
+    ExpiresAt time.Time  `json:"expires_at"`
+    MFA       bool       `json:"mfa"` // the login completed a second factor
+    UsedAt    *time.Time `json:"used_at,omitempty"`    // set when rotated into a new token
+    RevokedAt *time.Time `json:"revoked_at,omitempty"` // set on logout or reuse detection
+}
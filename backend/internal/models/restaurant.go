@@ -12,19 +12,99 @@ type Restaurant struct {
     DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
     Name        string         `gorm:"size:255" json:"name" validate:"required"`
     Address     string         `gorm:"size:255" json:"address" validate:"required"`
-    Category    string         `gorm:"size:100" json:"category" validate:"required"`
+    Category    string         `gorm:"size:100" json:"category"` // name of the primary cuisine among the tags
     Description string         `gorm:"type:text" json:"description" validate:"required"`
     Photos      []Photo        `json:"photos" gorm:"foreignKey:RestaurantID"`
     CreatedByID uint           `json:"created_by"`
     CreatedBy   User           `gorm:"foreignKey:CreatedByID" validate:"-"`
     Reviews     []Review       `json:"reviews"`
     Status      string         `gorm:"size:20" json:"status"` // "pending", "approved", "rejected"
+    Latitude    *float64       `gorm:"index:idx_restaurants_location" json:"latitude"`
+    Longitude   *float64       `gorm:"index:idx_restaurants_location" json:"longitude"`
+    Distance    *float64       `gorm:"->;-:migration" json:"distance,omitempty"` // km from the searched point, only set by location searches
+    Street      string         `gorm:"size:255" json:"street"`
+    City        string         `gorm:"size:100;index" json:"city"`
+    PostalCode  string         `gorm:"size:20" json:"postal_code"`
+    Country     string         `gorm:"size:2" json:"country"` // ISO 3166-1 alpha-2 code
+    GeocodingFailed bool       `gorm:"default:false;index" json:"geocoding_failed"` // the address could not be located, a moderator should check it
+    TimeZone    string         `gorm:"size:64" json:"time_zone"` // IANA time zone the opening hours are in
+    Tags        []Tag          `gorm:"many2many:restaurant_tags" json:"tags"`
+    PriceLevel  *int           `gorm:"index" json:"price_level"` // 1 (cheap) to 4 (expensive)
+    Dietary     DietaryOptions `gorm:"embedded;embeddedPrefix:dietary_" json:"dietary"`
+    Amenities   Amenities      `gorm:"embedded;embeddedPrefix:amenity_" json:"amenities"`
 }
 
 // New input struct for creating a restaurant
 type CreateRestaurantInput struct {
     Name        string `json:"name" validate:"required"`
     Address     string `json:"address" validate:"required"`
-    Category    string `json:"category" validate:"required"`
+    Category    string `json:"category" validate:"max=100"` // matched against the taxonomy when no tags are given
     Description string `json:"description" validate:"required"`
-}
\ No newline at end of file
+    Tags        []string `json:"tags" validate:"max=20,dive,required"` // tag slugs
+    PriceLevel  *int     `json:"price_level" validate:"omitempty,min=1,max=4"`
+    Dietary     DietaryOptions `json:"dietary"`
+    Amenities   Amenities      `json:"amenities"`
+    Latitude    *float64 `json:"latitude" validate:"required_with=Longitude,omitempty,min=-90,max=90"`
+    Longitude   *float64 `json:"longitude" validate:"required_with=Latitude,omitempty,min=-180,max=180"`
+}
+
+// Input struct for moderators setting a restaurant's location by hand
+type SetLocationInput struct {
+    Latitude   *float64 `json:"latitude" validate:"required,min=-90,max=90"`
+    Longitude  *float64 `json:"longitude" validate:"required,min=-180,max=180"`
+    Street     string   `json:"street" validate:"max=255"`
+    City       string   `json:"city" validate:"max=100"`
+    PostalCode string   `json:"postal_code" validate:"max=20"`
+    Country    string   `json:"country" validate:"omitempty,len=2"`
+}
+
+// Input struct for editing a restaurant; omitted fields are left unchanged. The category
+// follows the restaurant's tags and cannot be edited.
+type UpdateRestaurantInput struct {
+    Name        *string `json:"name" validate:"omitempty,min=1,max=255"`
+    Address     *string `json:"address" validate:"omitempty,min=1,max=255"`
+    Description *string `json:"description" validate:"omitempty,min=1"`
+}
+
+// RestaurantDetail is a single listing with its rating summary and the first page of reviews
+type RestaurantDetail struct {
+    ID              uint               `json:"id"`
+    CreatedAt       time.Time          `json:"created_at"`
+    UpdatedAt       time.Time          `json:"updated_at"`
+    Name            string             `json:"name"`
+    Address         string             `json:"address"`
+    Category        string             `json:"category"`
+    Description     string             `json:"description"`
+    Status          string             `json:"status"`
+    CreatedByID     uint               `json:"created_by"`
+    Latitude        *float64           `json:"latitude"`
+    Longitude       *float64           `json:"longitude"`
+    Street          string             `json:"street"`
+    City            string             `json:"city"`
+    PostalCode      string             `json:"postal_code"`
+    Country         string             `json:"country"`
+    AverageRating   float64            `json:"average_rating"`
+    ReviewCount     int64              `json:"review_count"`
+    RatingHistogram map[int]int64      `json:"rating_histogram"` // number of reviews for each rating from 1 to 5
+    CoverPhoto      *Photo             `json:"cover_photo"`
+    Photos          []Photo            `json:"photos"`
+    Tags            []Tag              `json:"tags"`
+    PriceLevel      *int               `json:"price_level"`
+    Dietary         DietaryOptions     `json:"dietary"`
+    Amenities       Amenities          `json:"amenities"`
+    Reviews         []RestaurantReview `json:"reviews"`
+    OpeningHours    *OpeningHoursSchedule `json:"opening_hours"` // null when the hours are unknown
+    *OpenStatus
+}
+
+// RestaurantReview is a review shown on a restaurant page with its author's public details
+type RestaurantReview struct {
+    ID          uint      `json:"id"`
+    CreatedAt   time.Time `json:"created_at"`
+    Rating      int       `json:"rating"`
+    Comment     string    `json:"comment"`
+    UserID      uint      `json:"user_id"`
+    Username    string    `json:"username"`
+    DisplayName string    `json:"display_name"`
+    AvatarURL   string    `json:"avatar_url"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+    "time"
+)
+
+const (
+    EditStatusPending  = "pending"
+    EditStatusApproved = "approved"
+    EditStatusRejected = "rejected"
+)
+
+// RestaurantEdit is a change to an approved listing waiting for a moderator.
+// Changes maps fields to their proposed values and Original to the values they had
+// when the edit was submitted. Fields are column names, "tags" for the comma-separated
+// tag slugs or "hours" for the opening hours as a JSON schedule; revision snapshots use
+// the same fields.
+type RestaurantEdit struct {
+    ID           uint              `gorm:"primaryKey" json:"id"`
+    CreatedAt    time.Time         `json:"created_at"`
+    UpdatedAt    time.Time         `json:"updated_at"`
+    RestaurantID uint              `gorm:"index" json:"restaurant_id"`
+    EditedByID   uint              `gorm:"index" json:"edited_by"`
+    Changes      map[string]string `gorm:"serializer:json" json:"changes"`
+    Original     map[string]string `gorm:"serializer:json" json:"original"`
+    Status       string            `gorm:"size:20;index" json:"status"`
+    ReviewedByID *uint             `json:"reviewed_by,omitempty"`
+    ReviewedAt   *time.Time        `json:"reviewed_at,omitempty"`
+}
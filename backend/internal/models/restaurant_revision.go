@@ -0,0 +1,26 @@
+package models
+
+import (
+    "time"
+)
+
+const (
+    RevisionCreated    = "created"
+    RevisionApproved   = "approved"
+    RevisionRejected   = "rejected"
+    RevisionEdited     = "edited"
+    RevisionRolledBack = "rolled_back"
+)
+
+// RestaurantRevision records what a restaurant looked like after a change,
+// who made it and which fields it changed
+type RestaurantRevision struct {
+    ID           uint              `gorm:"primaryKey" json:"id"`
+    CreatedAt    time.Time         `json:"created_at"`
+    RestaurantID uint              `gorm:"index" json:"restaurant_id"`
+    AuthorID     uint              `gorm:"index" json:"author_id"`
+    Action       string            `gorm:"size:20" json:"action"`
+    Note         string            `gorm:"size:255" json:"note,omitempty"`
+    Snapshot     map[string]string `gorm:"serializer:json" json:"snapshot"`
+    Changes      []FieldChange     `gorm:"serializer:json" json:"changes"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// SearchResult is a restaurant matching a full-text search. NameHighlight and Snippet are
+// HTML-escaped with the matching words wrapped in <mark> tags.
+type SearchResult struct {
+    ID            uint    `json:"id"`
+    Name          string  `json:"name"`
+    Category      string  `json:"category"`
+    Address       string  `json:"address"`
+    AverageRating float64 `json:"average_rating"`
+    ReviewCount   int64   `json:"review_count"`
+    Rank          float64 `json:"rank"` // text relevance weighted by rating, higher is better
+    NameHighlight string  `json:"name_highlight"`
+    Snippet       string  `json:"snippet"`
+}
+
+const (
+    AutocompleteRestaurant = "restaurant"
+    AutocompleteCuisine    = "cuisine"
+    AutocompleteCity       = "city"
+)
+
+// AutocompleteSuggestion is one entry of the typeahead list. ID is set for restaurants and Slug for cuisines.
+type AutocompleteSuggestion struct {
+    Type  string `json:"type"`
+    Label string `json:"label"`
+    ID    uint   `json:"id,omitempty"`
+    Slug  string `json:"slug,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+    "time"
+)
+
+// SigningKey is a private key used to sign JWTs, identified in token headers by its KID.
+// Status is "active" for the key that signs new tokens, "verify" for keys that were
+// rotated out but still validate tokens issued before the rotation, and "retired".
+type SigningKey struct {
+    ID         uint       `gorm:"primaryKey" json:"id"`
+    CreatedAt  time.Time  `json:"created_at"`
+    UpdatedAt  time.Time  `json:"updated_at"`
+    KID        string     `gorm:"size:64;uniqueIndex" json:"kid"`
+    Algorithm  string     `gorm:"size:10" json:"algorithm"` // "RS256" or "EdDSA"
+    PrivateKey string     `gorm:"type:text" json:"-"`       // PKCS #8 PEM
+    Status     string     `gorm:"size:20;index" json:"status"`
+    RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+    "time"
+)
+
+// FieldChange is a proposed change to a single restaurant column
+type FieldChange struct {
+    Field string `json:"field"`
+    From  string `json:"from"`
+    To    string `json:"to"`
+}
+
+// SuggestedEdit is a correction to someone else's listing proposed by a community member.
+// It uses the same statuses as RestaurantEdit.
+type SuggestedEdit struct {
+    ID            uint          `gorm:"primaryKey" json:"id"`
+    CreatedAt     time.Time     `json:"created_at"`
+    UpdatedAt     time.Time     `json:"updated_at"`
+    RestaurantID  uint          `gorm:"index" json:"restaurant_id"`
+    SuggestedByID uint          `gorm:"index" json:"suggested_by"`
+    Changes       []FieldChange `gorm:"serializer:json" json:"changes"`
+    Comment       string        `gorm:"type:text" json:"comment"`
+    Status        string        `gorm:"size:20;index" json:"status"`
+    ReviewedByID  *uint         `json:"reviewed_by,omitempty"`
+    ReviewedAt    *time.Time    `json:"reviewed_at,omitempty"`
+}
+
+type SuggestEditInput struct {
+    UpdateRestaurantInput
+    Tags    *[]string `json:"tags" validate:"omitempty,min=1,max=20,dive,required"` // tag slugs; the category follows them
+    Comment string    `json:"comment" validate:"max=1000"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+    "time"
+)
+
+const (
+    TagKindCuisine = "cuisine"
+    TagKindTag     = "tag"
+)
+
+// Tag is an entry of the managed taxonomy of cuisines and other tags. Cuisines
+// can be nested, e.g. Thai under Asian.
+type Tag struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+    Name      string    `gorm:"size:100" json:"name"`
+    Slug      string    `gorm:"size:100;uniqueIndex" json:"slug"`
+    Kind      string    `gorm:"size:20;index" json:"kind"`
+    ParentID  *uint     `gorm:"index" json:"parent_id,omitempty"`
+    Aliases   []string  `gorm:"serializer:json" json:"aliases"` // other spellings that map to this tag, used when migrating free-text categories
+}
+
+type CreateTagInput struct {
+    Name     string   `json:"name" validate:"required,max=100"`
+    Slug     string   `json:"slug" validate:"omitempty,max=100"` // derived from the name when empty
+    Kind     string   `json:"kind" validate:"required,oneof=cuisine tag"`
+    ParentID *uint    `json:"parent_id"`
+    Aliases  []string `json:"aliases" validate:"omitempty,dive,max=100"`
+}
+
+// Input struct for editing a tag; omitted fields are left unchanged and a parent_id of 0 moves the tag to the top level
+type UpdateTagInput struct {
+    Name     *string   `json:"name" validate:"omitempty,min=1,max=100"`
+    ParentID *uint     `json:"parent_id"`
+    Aliases  *[]string `json:"aliases" validate:"omitempty,dive,max=100"`
+}
+
+type SetTagsInput struct {
+    Tags []string `json:"tags" validate:"min=1,max=20,dive,required"` // tag slugs; a listing keeps at least one
+}
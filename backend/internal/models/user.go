@@ -12,9 +12,80 @@ type User struct {
     DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
     Username  string         `gorm:"uniqueIndex;size:100" json:"username" validate:"required"`
     Email     string         `gorm:"uniqueIndex;size:100" json:"email" validate:"required,email"`
-    Password  string         `gorm:"size:255" json:"password" validate:"required"`
-    Role      string         `gorm:"size:20" json:"role"` // "user" or "admin"
+    Password  string         `gorm:"size:255" json:"-" validate:"required"`
+    Passwordless bool        `gorm:"default:false" json:"passwordless"` // created through OIDC and never set a password, so the password is unknown to the user
+    Role      string         `gorm:"size:20" json:"role"` // see rbac for the available roles
+    EmailVerified   bool       `gorm:"default:false" json:"email_verified"`
+    EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+    TOTPEnabled     bool       `gorm:"default:false" json:"totp_enabled"`
+    TOTPSecret      string     `gorm:"size:64" json:"-"`
+    TOTPLastStep    int64      `json:"-"` // last accepted time step, prevents code replay
     Reputation int           `json:"reputation" gorm:"default:0"`
+    DisplayName        string   `gorm:"size:100" json:"display_name"`
+    Bio                string   `gorm:"type:text" json:"bio"`
+    AvatarURL          string   `gorm:"size:255" json:"avatar_url"`
+    HomeCity           string   `gorm:"size:100" json:"home_city"`
+    DietaryPreferences []string `gorm:"serializer:json" json:"dietary_preferences"`
+    SuspendedUntil     *time.Time `json:"suspended_until,omitempty"`
+    SuspensionReason   string     `gorm:"size:500" json:"suspension_reason,omitempty"`
+    BannedAt           *time.Time `json:"banned_at,omitempty"`
+    BanReason          string     `gorm:"size:500" json:"ban_reason,omitempty"`
+    DeletionRequestedAt  *time.Time `json:"deletion_requested_at,omitempty"`
+    DeletionScheduledFor *time.Time `gorm:"index" json:"deletion_scheduled_for,omitempty"` // account is anonymized after this time unless the request is cancelled
     Listings  []Restaurant   `json:"listings" gorm:"foreignKey:CreatedByID"`
     Reviews   []Review       `json:"reviews"`
-}
\ No newline at end of file
+}
+
+const (
+    AccountActive    = "active"
+    AccountSuspended = "suspended"
+    AccountBanned    = "banned"
+)
+
+// AccountStatus reports whether the user may currently use the site
+func (u User) AccountStatus() string {
+    if u.BannedAt != nil {
+        return AccountBanned
+    }
+    if u.SuspendedUntil != nil && time.Now().Before(*u.SuspendedUntil) {
+        return AccountSuspended
+    }
+    return AccountActive
+}
+
+// Input struct for registering a user
+type RegisterInput struct {
+    Username string `json:"username" validate:"required"`
+    Email    string `json:"email" validate:"required,email"`
+    Password string `json:"password" validate:"required"`
+}
+
+// Input struct for editing the logged in user's profile; omitted fields are left unchanged
+type UpdateProfileInput struct {
+    DisplayName        *string   `json:"display_name" validate:"omitempty,max=100"`
+    Bio                *string   `json:"bio" validate:"omitempty,max=1000"`
+    HomeCity           *string   `json:"home_city" validate:"omitempty,max=100"`
+    DietaryPreferences *[]string `json:"dietary_preferences" validate:"omitempty,dive,oneof=vegetarian vegan pescatarian halal kosher gluten_free dairy_free nut_free"`
+}
+
+type ChangePasswordInput struct {
+    CurrentPassword string `json:"current_password" validate:"required"`
+    NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
+
+type DeleteAccountInput struct {
+    Password string `json:"password"` // required unless the account is passwordless, which confirms by email instead
+}
+
+type ConfirmAccountDeletionInput struct {
+    Token string `json:"token" validate:"required"`
+}
+
+type SuspendUserInput struct {
+    Duration string `json:"duration" validate:"required"` // e.g. "72h"
+    Reason   string `json:"reason" validate:"required,max=500"`
+}
+
+type BanUserInput struct {
+    Reason string `json:"reason" validate:"required,max=500"`
+}
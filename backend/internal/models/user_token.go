@@ -0,0 +1,22 @@
+package models
+
+import (
+    "time"
+)
+
+const (
+    TokenPurposePasswordReset     = "password_reset"
+    TokenPurposeEmailVerification = "email_verification"
+    TokenPurposeAccountDeletion   = "account_deletion"
+)
+
+// UserToken is a single-use, expiring token emailed to a user. Only the hash is stored.
+type UserToken struct {
+    ID        uint       `gorm:"primaryKey" json:"id"`
+    CreatedAt time.Time  `json:"created_at"`
+    UserID    uint       `gorm:"index" json:"user_id"`
+    Purpose   string     `gorm:"size:30;index" json:"purpose"`
+    TokenHash string     `gorm:"size:64;uniqueIndex" json:"-"`
+    ExpiresAt time.Time  `json:"expires_at"`
+    UsedAt    *time.Time `json:"used_at,omitempty"`
+}
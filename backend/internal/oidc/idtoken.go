@@ -0,0 +1,98 @@
+package oidc
+
+import (
+    "context"
+    "crypto/subtle"
+    "fmt"
+
+    "github.com/dgrijalva/jwt-go"
+)
+
+// Claims are the ID token claims used to link or create an account
+type Claims struct {
+    Subject           string
+    Email             string
+    EmailVerified     bool
+    Name              string
+    PreferredUsername string
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+    token, err := jwt.Parse(rawIDToken, func(token *jwt.Token) (interface{}, error) {
+        switch token.Method.(type) {
+        case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+        default:
+            return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+        }
+        kid, _ := token.Header["kid"].(string)
+        set, err := p.keySet(ctx, false)
+        if err != nil {
+            return nil, err
+        }
+        key, ok := set.Find(kid)
+        if !ok {
+            // The provider may have rotated its keys since we cached them
+            if set, err = p.keySet(ctx, true); err != nil {
+                return nil, err
+            }
+            if key, ok = set.Find(kid); !ok {
+                return nil, fmt.Errorf("unknown signing key %q", kid)
+            }
+        }
+        return key.PublicKey()
+    })
+    if err != nil {
+        return nil, fmt.Errorf("invalid id token: %w", err)
+    }
+    claims, ok := token.Claims.(jwt.MapClaims)
+    if !ok || !token.Valid {
+        return nil, fmt.Errorf("invalid id token")
+    }
+
+    if iss, _ := claims["iss"].(string); iss != p.Issuer {
+        return nil, fmt.Errorf("id token issuer mismatch")
+    }
+    if !hasAudience(claims["aud"], p.ClientID) {
+        return nil, fmt.Errorf("id token audience mismatch")
+    }
+    if _, ok := claims["exp"]; !ok {
+        return nil, fmt.Errorf("id token has no expiry")
+    }
+    tokenNonce, _ := claims["nonce"].(string)
+    if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+        return nil, fmt.Errorf("id token nonce mismatch")
+    }
+
+    result := &Claims{}
+    result.Subject, _ = claims["sub"].(string)
+    result.Email, _ = claims["email"].(string)
+    result.Name, _ = claims["name"].(string)
+    result.PreferredUsername, _ = claims["preferred_username"].(string)
+    switch verified := claims["email_verified"].(type) {
+    case bool:
+        result.EmailVerified = verified
+    case string:
+        // Some providers send the claim as a string
+        result.EmailVerified = verified == "true"
+    }
+    if result.Subject == "" {
+        return nil, fmt.Errorf("id token has no subject")
+    }
+    return result, nil
+}
+
+// hasAudience reports whether the aud claim (a string or an array) contains clientID
+func hasAudience(aud interface{}, clientID string) bool {
+    switch aud := aud.(type) {
+    case string:
+        return aud == clientID
+    case []interface{}:
+        for _, a := range aud {
+            if a == clientID {
+                return true
+            }
+        }
+    }
+    return false
+}
@@ -0,0 +1,200 @@
+package oidc
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/jwk"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Providers holds the identity providers configured through OIDC_PROVIDERS
+var Providers = map[string]*Provider{}
+
+// Discovery is the subset of the OpenID Provider metadata we use
+type Discovery struct {
+    Issuer                string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect identity provider using the authorization code flow with PKCE
+type Provider struct {
+    Name         string
+    Issuer       string
+    ClientID     string
+    ClientSecret string
+    RedirectURL  string
+    Scopes       []string
+
+    mu        sync.Mutex
+    discovery *Discovery
+    keys      jwk.Set
+    keysAt    time.Time
+}
+
+// Init loads providers from the environment.
+// OIDC_PROVIDERS is a comma separated list of names; each name reads
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET,
+// OIDC_<NAME>_REDIRECT_URL and optionally OIDC_<NAME>_SCOPES.
+func Init() {
+    Providers = map[string]*Provider{}
+    for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+        name = strings.ToLower(strings.TrimSpace(name))
+        if name == "" {
+            continue
+        }
+        prefix := "OIDC_" + strings.ToUpper(name) + "_"
+        Providers[name] = &Provider{
+            Name:         name,
+            Issuer:       strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+            ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+            ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+            RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+            Scopes:       strings.Fields(configs.GetEnv(prefix+"SCOPES", "openid email profile")),
+        }
+    }
+}
+
+// Discover fetches and caches the provider's discovery document
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.discovery != nil {
+        return p.discovery, nil
+    }
+
+    var d Discovery
+    if err := getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+        return nil, fmt.Errorf("oidc discovery: %w", err)
+    }
+    if strings.TrimSuffix(d.Issuer, "/") != p.Issuer {
+        return nil, fmt.Errorf("oidc discovery: issuer mismatch %q", d.Issuer)
+    }
+    p.discovery = &d
+    return p.discovery, nil
+}
+
+// AuthCodeURL builds the authorization request URL
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+    d, err := p.Discover(ctx)
+    if err != nil {
+        return "", err
+    }
+    v := url.Values{}
+    v.Set("response_type", "code")
+    v.Set("client_id", p.ClientID)
+    v.Set("redirect_uri", p.RedirectURL)
+    v.Set("scope", strings.Join(p.Scopes, " "))
+    v.Set("state", state)
+    v.Set("nonce", nonce)
+    v.Set("code_challenge", CodeChallenge(codeVerifier))
+    v.Set("code_challenge_method", "S256")
+
+    separator := "?"
+    if strings.Contains(d.AuthorizationEndpoint, "?") {
+        separator = "&"
+    }
+    return d.AuthorizationEndpoint + separator + v.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens and returns the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+    d, err := p.Discover(ctx)
+    if err != nil {
+        return "", err
+    }
+    form := url.Values{}
+    form.Set("grant_type", "authorization_code")
+    form.Set("code", code)
+    form.Set("redirect_uri", p.RedirectURL)
+    form.Set("client_id", p.ClientID)
+    form.Set("code_verifier", codeVerifier)
+    if p.ClientSecret != "" {
+        form.Set("client_secret", p.ClientSecret)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    resp, err := httpClient.Do(req)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    var body struct {
+        IDToken          string `json:"id_token"`
+        Error            string `json:"error"`
+        ErrorDescription string `json:"error_description"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        return "", fmt.Errorf("oidc token response: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK || body.Error != "" {
+        return "", fmt.Errorf("oidc token exchange failed: %s %s", body.Error, body.ErrorDescription)
+    }
+    if body.IDToken == "" {
+        return "", fmt.Errorf("oidc token response has no id_token")
+    }
+    return body.IDToken, nil
+}
+
+// keySet returns the provider's signing keys, refetching them when refresh is set
+// and the cached copy is older than a minute (so a rotated kid is picked up quickly)
+func (p *Provider) keySet(ctx context.Context, refresh bool) (jwk.Set, error) {
+    d, err := p.Discover(ctx)
+    if err != nil {
+        return jwk.Set{}, err
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if len(p.keys.Keys) > 0 && (!refresh || time.Since(p.keysAt) < time.Minute) {
+        return p.keys, nil
+    }
+    var set jwk.Set
+    if err := getJSON(ctx, d.JWKSURI, &set); err != nil {
+        return jwk.Set{}, fmt.Errorf("oidc jwks: %w", err)
+    }
+    p.keys = set
+    p.keysAt = time.Now()
+    return set, nil
+}
+
+// CodeChallenge derives the S256 PKCE code challenge from a verifier
+func CodeChallenge(verifier string) string {
+    sum := sha256.Sum256([]byte(verifier))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func getJSON(ctx context.Context, url string, v interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+    resp, err := httpClient.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("GET %s: %s", url, resp.Status)
+    }
+    return json.NewDecoder(resp.Body).Decode(v)
+}
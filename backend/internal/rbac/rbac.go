@@ -0,0 +1,105 @@
+package rbac
+
+import (
+    "sort"
+)
+
+// Roles
+const (
+    RoleUser      = "user"
+    RoleEditor    = "editor"
+    RoleModerator = "moderator"
+    RoleAdmin     = "admin"
+    RoleOwner     = "owner" // site owner, the only role that can manage admins
+)
+
+// Permissions
+const (
+    RestaurantsApprove = "restaurants.approve"
+    RestaurantsEdit    = "restaurants.edit"
+    TagsManage         = "tags.manage"
+    ReviewsDelete      = "reviews.delete"
+    UsersView          = "users.view"
+    UsersBan           = "users.ban"
+    UsersRoles         = "users.roles"
+    AdminsManage       = "admins.manage"
+)
+
+// API key scopes for actions every user may take, on top of the permissions above
+const (
+    ScopeListingsWrite = "listings.write" // add and edit restaurants with their tags, hours, attributes, menus, photos and suggestions
+    ScopeReviewsWrite  = "reviews.write"
+)
+
+var userScopes = []string{ScopeListingsWrite, ScopeReviewsWrite}
+
+// keyPermissions are the permissions an API key can use. Managing roles, bans and admins
+// needs an interactive login.
+var keyPermissions = []string{RestaurantsApprove, RestaurantsEdit, TagsManage, ReviewsDelete, UsersView}
+
+var rolePermissions = map[string][]string{
+    RoleUser:      {},
+    RoleEditor:    {RestaurantsEdit},
+    RoleModerator: {RestaurantsApprove, RestaurantsEdit, ReviewsDelete, UsersView},
+    RoleAdmin:     {RestaurantsApprove, RestaurantsEdit, TagsManage, ReviewsDelete, UsersView, UsersBan, UsersRoles},
+    RoleOwner:     {RestaurantsApprove, RestaurantsEdit, TagsManage, ReviewsDelete, UsersView, UsersBan, UsersRoles, AdminsManage},
+}
+
+// Can reports whether the role grants the permission
+func Can(role, permission string) bool {
+    for _, p := range rolePermissions[role] {
+        if p == permission {
+            return true
+        }
+    }
+    return false
+}
+
+// ValidPermission reports whether the permission exists
+func ValidPermission(permission string) bool {
+    for _, permissions := range rolePermissions {
+        for _, p := range permissions {
+            if p == permission {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// ValidScope reports whether an API key of a user with the role may be limited to the scope
+func ValidScope(role, scope string) bool {
+    for _, s := range userScopes {
+        if s == scope {
+            return true
+        }
+    }
+    for _, p := range keyPermissions {
+        if p == scope {
+            return Can(role, scope)
+        }
+    }
+    return false
+}
+
+// ValidRole reports whether the role exists
+func ValidRole(role string) bool {
+    _, ok := rolePermissions[role]
+    return ok
+}
+
+// IsPrivileged reports whether the role is one of the administrative roles
+// that manage other admins' work and may be required to use two-factor authentication
+func IsPrivileged(role string) bool {
+    return role == RoleAdmin || role == RoleOwner
+}
+
+// Roles returns every role with its permissions
+func Roles() map[string][]string {
+    roles := make(map[string][]string, len(rolePermissions))
+    for role, permissions := range rolePermissions {
+        roles[role] = append([]string{}, permissions...)
+        sort.Strings(roles[role])
+    }
+    return roles
+}
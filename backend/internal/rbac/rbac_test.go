@@ -0,0 +1,125 @@
+package rbac
+
+import (
+    "testing"
+)
+
+func TestCan(t *testing.T) {
+    all := []string{RestaurantsApprove, RestaurantsEdit, TagsManage, ReviewsDelete, UsersView, UsersBan, UsersRoles, AdminsManage}
+    tests := []struct {
+        role    string
+        granted []string
+    }{
+        {RoleUser, nil},
+        {RoleEditor, []string{RestaurantsEdit}},
+        {RoleModerator, []string{RestaurantsApprove, RestaurantsEdit, ReviewsDelete, UsersView}},
+        {RoleAdmin, []string{RestaurantsApprove, RestaurantsEdit, TagsManage, ReviewsDelete, UsersView, UsersBan, UsersRoles}},
+        {RoleOwner, all},
+        {"superuser", nil},
+        {"", nil},
+    }
+    for _, tt := range tests {
+        granted := map[string]bool{}
+        for _, p := range tt.granted {
+            granted[p] = true
+        }
+        for _, permission := range all {
+            if got := Can(tt.role, permission); got != granted[permission] {
+                t.Errorf("Can(%q, %q) = %v, want %v", tt.role, permission, got, granted[permission])
+            }
+        }
+    }
+}
+
+func TestValidRole(t *testing.T) {
+    tests := []struct {
+        role string
+        want bool
+    }{
+        {RoleUser, true},
+        {RoleEditor, true},
+        {RoleModerator, true},
+        {RoleAdmin, true},
+        {RoleOwner, true},
+        {"Admin", false},
+        {"", false},
+    }
+    for _, tt := range tests {
+        if got := ValidRole(tt.role); got != tt.want {
+            t.Errorf("ValidRole(%q) = %v, want %v", tt.role, got, tt.want)
+        }
+    }
+}
+
+func TestValidPermission(t *testing.T) {
+    tests := []struct {
+        permission string
+        want       bool
+    }{
+        {RestaurantsApprove, true},
+        {AdminsManage, true},
+        {ScopeListingsWrite, false},
+        {"restaurants.delete", false},
+        {"", false},
+    }
+    for _, tt := range tests {
+        if got := ValidPermission(tt.permission); got != tt.want {
+            t.Errorf("ValidPermission(%q) = %v, want %v", tt.permission, got, tt.want)
+        }
+    }
+}
+
+func TestIsPrivileged(t *testing.T) {
+    tests := []struct {
+        role string
+        want bool
+    }{
+        {RoleUser, false},
+        {RoleEditor, false},
+        {RoleModerator, false},
+        {RoleAdmin, true},
+        {RoleOwner, true},
+    }
+    for _, tt := range tests {
+        if got := IsPrivileged(tt.role); got != tt.want {
+            t.Errorf("IsPrivileged(%q) = %v, want %v", tt.role, got, tt.want)
+        }
+    }
+}
+
+func TestRolesReturnsCopies(t *testing.T) {
+    roles := Roles()
+    if len(roles) != len(rolePermissions) {
+        t.Fatalf("Roles() has %d roles, want %d", len(roles), len(rolePermissions))
+    }
+    roles[RoleEditor][0] = AdminsManage
+    if Can(RoleEditor, AdminsManage) {
+        t.Error("changing the result of Roles() changed the editor's permissions")
+    }
+}
+
+func TestValidScope(t *testing.T) {
+    tests := []struct {
+        role  string
+        scope string
+        want  bool
+    }{
+        {RoleUser, ScopeListingsWrite, true},
+        {RoleUser, ScopeReviewsWrite, true},
+        {RoleUser, RestaurantsApprove, false},
+        {RoleEditor, RestaurantsEdit, true},
+        {RoleEditor, RestaurantsApprove, false},
+        {RoleModerator, RestaurantsApprove, true},
+        {RoleModerator, TagsManage, false},
+        {RoleAdmin, TagsManage, true},
+        {RoleAdmin, UsersBan, false},
+        {RoleAdmin, UsersRoles, false},
+        {RoleOwner, AdminsManage, false},
+        {RoleOwner, "listings.delete", false},
+    }
+    for _, tt := range tests {
+        if got := ValidScope(tt.role, tt.scope); got != tt.want {
+            t.Errorf("ValidScope(%q, %q) = %v, want %v", tt.role, tt.scope, got, tt.want)
+        }
+    }
+}
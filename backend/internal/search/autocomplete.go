@@ -0,0 +1,116 @@
+package search
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+// maxCachedPrefixes bounds the autocomplete cache; it is emptied when full
+const maxCachedPrefixes = 10000
+
+type cachedSuggestions struct {
+    suggestions []models.AutocompleteSuggestion
+    expires     time.Time
+}
+
+var (
+    cacheMu sync.Mutex
+    cache   = map[string]cachedSuggestions{}
+)
+
+// AutocompleteCacheTTL returns how long suggestions for a prefix are reused
+func AutocompleteCacheTTL() time.Duration {
+    return configs.GetDuration("AUTOCOMPLETE_CACHE_TTL", time.Minute)
+}
+
+// prefixPattern returns a LIKE pattern matching lowercase text that starts with prefix
+func prefixPattern(prefix string) string {
+    escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+    return escaped + "%"
+}
+
+// Autocomplete suggests cuisines, cities and approved restaurants whose name starts with
+// the prefix, in that order and at most limit of them. Results are cached per prefix and
+// limit for AutocompleteCacheTTL, so new listings can take that long to appear.
+func Autocomplete(prefix string, limit int) ([]models.AutocompleteSuggestion, error) {
+    prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+    if prefix == "" {
+        return []models.AutocompleteSuggestion{}, nil
+    }
+    key := fmt.Sprintf("%d:%s", limit, prefix)
+
+    cacheMu.Lock()
+    cached, ok := cache[key]
+    cacheMu.Unlock()
+    if ok && time.Now().Before(cached.expires) {
+        return cached.suggestions, nil
+    }
+
+    suggestions, err := autocomplete(prefix, limit)
+    if err != nil {
+        return nil, err
+    }
+
+    cacheMu.Lock()
+    if len(cache) >= maxCachedPrefixes {
+        cache = map[string]cachedSuggestions{}
+    }
+    cache[key] = cachedSuggestions{suggestions: suggestions, expires: time.Now().Add(AutocompleteCacheTTL())}
+    cacheMu.Unlock()
+    return suggestions, nil
+}
+
+// autocomplete queries the suggestions. Each type gets at most limit entries and the
+// combined list is cut to limit, so short lists of cuisines and cities are not crowded out.
+func autocomplete(prefix string, limit int) ([]models.AutocompleteSuggestion, error) {
+    pattern := prefixPattern(prefix)
+    suggestions := []models.AutocompleteSuggestion{}
+
+    // only cuisines that would find something
+    var cuisines []models.Tag
+    err := db.DB.Select("name, slug").
+        Where("kind = ? AND lower(name) LIKE ?", models.TagKindCuisine, pattern).
+        Where("EXISTS (SELECT 1 FROM restaurant_tags rt JOIN restaurants r ON r.id = rt.restaurant_id WHERE rt.tag_id = tags.id AND r.status = ? AND r.deleted_at IS NULL)", "approved").
+        Order("name").Limit(limit).Find(&cuisines).Error
+    if err != nil {
+        return nil, err
+    }
+    for _, tag := range cuisines {
+        suggestions = append(suggestions, models.AutocompleteSuggestion{Type: models.AutocompleteCuisine, Label: tag.Name, Slug: tag.Slug})
+    }
+
+    // cities spelled with different case are one city, shown with its most common spelling
+    var cities []string
+    err = db.DB.Model(&models.Restaurant{}).
+        Where("status = ? AND lower(city) LIKE ?", "approved", pattern).
+        Group("lower(city)").Order("COUNT(*) DESC, lower(city)").Limit(limit).
+        Pluck("mode() WITHIN GROUP (ORDER BY city)", &cities).Error
+    if err != nil {
+        return nil, err
+    }
+    for _, city := range cities {
+        suggestions = append(suggestions, models.AutocompleteSuggestion{Type: models.AutocompleteCity, Label: city})
+    }
+
+    var restaurants []models.Restaurant
+    err = db.DB.Select("id, name").
+        Where("status = ? AND lower(name) LIKE ?", "approved", pattern).
+        Order("name, id").Limit(limit).Find(&restaurants).Error
+    if err != nil {
+        return nil, err
+    }
+    for _, restaurant := range restaurants {
+        suggestions = append(suggestions, models.AutocompleteSuggestion{Type: models.AutocompleteRestaurant, Label: restaurant.Name, ID: restaurant.ID})
+    }
+
+    if len(suggestions) > limit {
+        suggestions = suggestions[:limit]
+    }
+    return suggestions, nil
+}
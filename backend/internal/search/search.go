@@ -0,0 +1,113 @@
+package search
+
+import (
+    "html"
+    "strings"
+    "unicode"
+
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "gorm.io/gorm"
+)
+
+// vectorSQL weights the name above the category, the category above the description
+// and the description above the address
+const vectorSQL = `setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(category, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(address, '')), 'D')`
+
+// Highlight markers are private use characters that ts_headline wraps matches in, so the
+// text can be HTML-escaped before they are turned into <mark> tags
+const (
+    startSel = "\uE000"
+    stopSel  = "\uE001"
+)
+
+// ts_headline options for the whole name and for snippets of the description
+var (
+    nameOptions    = "HighlightAll=true, StartSel=" + startSel + ", StopSel=" + stopSel
+    snippetOptions = "StartSel=" + startSel + ", StopSel=" + stopSel + `, MaxWords=30, MinWords=12, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+// MatchSQL matches restaurants against the tsquery in its only placeholder
+const MatchSQL = "restaurants.search_vector @@ to_tsquery('english', ?)"
+
+// Migrate adds the generated search_vector column to restaurants with its GIN index, and
+// the lowercase prefix indexes autocomplete uses. AutoMigrate cannot declare generated
+// columns or expression indexes, so this runs after it.
+func Migrate() error {
+    statements := []string{
+        `ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS search_vector tsvector
+            GENERATED ALWAYS AS (` + vectorSQL + `) STORED`,
+        "CREATE INDEX IF NOT EXISTS idx_restaurants_search ON restaurants USING GIN (search_vector)",
+        "CREATE INDEX IF NOT EXISTS idx_restaurants_name_prefix ON restaurants (lower(name) text_pattern_ops)",
+        "CREATE INDEX IF NOT EXISTS idx_restaurants_city_prefix ON restaurants (lower(city) text_pattern_ops)",
+        "CREATE INDEX IF NOT EXISTS idx_tags_name_prefix ON tags (lower(name) text_pattern_ops)",
+    }
+    for _, statement := range statements {
+        if err := db.DB.Exec(statement).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Query turns free text into a tsquery that requires every word, each matched as a prefix
+// so that "ital piz" finds "Italian Pizzeria". It returns false when the text has no words.
+func Query(text string) (string, bool) {
+    words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    if len(words) == 0 {
+        return "", false
+    }
+    for i, w := range words {
+        words[i] = w + ":*"
+    }
+    return strings.Join(words, " & "), true
+}
+
+// Restaurants returns the approved restaurants matching the tsquery, best first. The text
+// rank is scaled by the average rating so that, between equally relevant places, better
+// rated ones come first: a 5 star average doubles the rank, unrated places keep it as is.
+func Restaurants(query string, limit, offset int) ([]models.SearchResult, int64, error) {
+    results := []models.SearchResult{}
+    var total int64
+    matches := func() *gorm.DB {
+        return db.DB.Table("restaurants").
+            Where("restaurants.deleted_at IS NULL AND restaurants.status = ?", "approved").
+            Where(MatchSQL, query)
+    }
+    if err := matches().Count(&total).Error; err != nil {
+        return nil, 0, err
+    }
+
+    err := matches().
+        Select(`restaurants.id, restaurants.name, restaurants.category, restaurants.address,
+            COALESCE(r.average_rating, 0) AS average_rating, COALESCE(r.review_count, 0) AS review_count,
+            CAST(ts_rank(restaurants.search_vector, to_tsquery('english', ?)) AS double precision)
+                * (1 + CAST(COALESCE(r.average_rating, 0) AS double precision) / 5) AS rank,
+            ts_headline('english', restaurants.name, to_tsquery('english', ?), ?) AS name_highlight,
+            ts_headline('english', restaurants.description, to_tsquery('english', ?), ?) AS snippet`,
+            query, query, nameOptions, query, snippetOptions).
+        Joins(`LEFT JOIN (SELECT restaurant_id, AVG(rating) AS average_rating, COUNT(*) AS review_count
+            FROM reviews WHERE deleted_at IS NULL GROUP BY restaurant_id) r ON r.restaurant_id = restaurants.id`).
+        Order("rank DESC, restaurants.id").
+        Limit(limit).Offset(offset).
+        Find(&results).Error
+    if err != nil {
+        return nil, 0, err
+    }
+    for i := range results {
+        results[i].NameHighlight = mark(results[i].NameHighlight)
+        results[i].Snippet = mark(results[i].Snippet)
+    }
+    return results, total, nil
+}
+
+// mark escapes a headline and replaces the highlight markers with <mark> tags
+func mark(headline string) string {
+    escaped := html.EscapeString(headline)
+    return strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>").Replace(escaped)
+}
@@ -0,0 +1,191 @@
+package taxonomy
+
+import (
+    "strings"
+    "unicode"
+
+    "github.com/pp00x/foodiebaba/internal/db"
+    "github.com/pp00x/foodiebaba/internal/models"
+    "github.com/pp00x/foodiebaba/pkg/logger"
+    "gorm.io/gorm"
+)
+
+type seedTag struct {
+    name     string
+    kind     string
+    aliases  []string
+    children []seedTag
+}
+
+// defaults is the taxonomy a new installation starts with
+var defaults = []seedTag{
+    {name: "Asian", kind: models.TagKindCuisine, aliases: []string{"oriental", "pan asian"}, children: []seedTag{
+        {name: "Chinese", kind: models.TagKindCuisine, aliases: []string{"cantonese", "sichuan", "szechuan", "dim sum"}},
+        {name: "Japanese", kind: models.TagKindCuisine, aliases: []string{"sushi", "ramen"}},
+        {name: "Korean", kind: models.TagKindCuisine, aliases: []string{"korean bbq"}},
+        {name: "Thai", kind: models.TagKindCuisine},
+        {name: "Vietnamese", kind: models.TagKindCuisine, aliases: []string{"pho"}},
+        {name: "Indian", kind: models.TagKindCuisine, aliases: []string{"north indian", "south indian", "curry"}},
+    }},
+    {name: "European", kind: models.TagKindCuisine, children: []seedTag{
+        {name: "Italian", kind: models.TagKindCuisine, aliases: []string{"pizza", "pasta", "pizzeria", "trattoria"}},
+        {name: "French", kind: models.TagKindCuisine, aliases: []string{"bistro", "brasserie"}},
+        {name: "Spanish", kind: models.TagKindCuisine, aliases: []string{"tapas"}},
+        {name: "Greek", kind: models.TagKindCuisine},
+    }},
+    {name: "American", kind: models.TagKindCuisine, aliases: []string{"burgers", "burger", "diner", "bbq", "barbecue"}},
+    {name: "Mexican", kind: models.TagKindCuisine, aliases: []string{"tex mex", "tacos", "taqueria"}},
+    {name: "Middle Eastern", kind: models.TagKindCuisine, aliases: []string{"lebanese", "turkish", "falafel", "kebab"}},
+    {name: "Seafood", kind: models.TagKindCuisine, aliases: []string{"fish"}},
+    {name: "Cafe", kind: models.TagKindTag, aliases: []string{"coffee", "coffee shop", "café"}},
+    {name: "Bakery", kind: models.TagKindTag, aliases: []string{"desserts", "pastry", "patisserie"}},
+    {name: "Fast Food", kind: models.TagKindTag, aliases: []string{"quick service", "takeaway"}},
+    {name: "Fine Dining", kind: models.TagKindTag},
+    {name: "Street Food", kind: models.TagKindTag},
+    {name: "Bar", kind: models.TagKindTag, aliases: []string{"pub", "brewery"}},
+}
+
+// Slugify turns a name into a lowercase slug such as "middle-eastern"
+func Slugify(name string) string {
+    return strings.Join(words(name), "-")
+}
+
+// words splits text into lowercase words of letters and digits
+func words(text string) []string {
+    return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+// Seed creates the default taxonomy when there are no tags yet
+func Seed() error {
+    var count int64
+    if err := db.DB.Model(&models.Tag{}).Count(&count).Error; err != nil || count > 0 {
+        return err
+    }
+    return db.DB.Transaction(func(tx *gorm.DB) error {
+        return seed(tx, defaults, nil)
+    })
+}
+
+func seed(tx *gorm.DB, tags []seedTag, parentID *uint) error {
+    for _, t := range tags {
+        tag := models.Tag{Name: t.name, Slug: Slugify(t.name), Kind: t.kind, ParentID: parentID, Aliases: t.aliases}
+        if err := tx.Create(&tag).Error; err != nil {
+            return err
+        }
+        if err := seed(tx, t.children, &tag.ID); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Descendants returns the IDs of the tag and every tag nested below it
+func Descendants(tags []models.Tag, id uint) []uint {
+    ids := []uint{id}
+    for i := 0; i < len(ids); i++ {
+        for _, tag := range tags {
+            if tag.ParentID != nil && *tag.ParentID == ids[i] {
+                ids = append(ids, tag.ID)
+            }
+        }
+    }
+    return ids
+}
+
+// noiseWords are dropped from free-text categories before matching, so that
+// "Italian food" and "Thai cuisine" match their tags
+var noiseWords = map[string]bool{"food": true, "foods": true, "cuisine": true, "restaurant": true, "kitchen": true, "house": true}
+
+func normalize(text string) string {
+    var kept []string
+    for _, w := range words(text) {
+        if !noiseWords[w] {
+            kept = append(kept, w)
+        }
+    }
+    return strings.Join(kept, " ")
+}
+
+// Match finds the tag a free-text category refers to, allowing small typos
+// such as "Itallian". It returns false when nothing is close enough.
+func Match(tags []models.Tag, category string) (models.Tag, bool) {
+    text := normalize(category)
+    if text == "" {
+        return models.Tag{}, false
+    }
+    // tolerate one typo in short words and two in longer ones, none in very short ones
+    allowed := 1
+    if len(text) >= 8 {
+        allowed = 2
+    } else if len(text) < 4 {
+        allowed = 0
+    }
+    best, bestDistance := -1, 0
+    for i, tag := range tags {
+        candidates := append([]string{tag.Name, strings.ReplaceAll(tag.Slug, "-", " ")}, tag.Aliases...)
+        for _, candidate := range candidates {
+            candidate = normalize(candidate)
+            if candidate == text {
+                return tag, true
+            }
+            if d := levenshtein(text, candidate); d <= allowed && (best < 0 || d < bestDistance) {
+                best, bestDistance = i, d
+            }
+        }
+    }
+    if best < 0 {
+        return models.Tag{}, false
+    }
+    return tags[best], true
+}
+
+// MigrateCategories tags every restaurant that has no tags yet with the taxonomy entry
+// its free-text category maps to. Categories that match nothing are logged and left alone.
+func MigrateCategories() error {
+    var tags []models.Tag
+    if err := db.DB.Find(&tags).Error; err != nil {
+        return err
+    }
+    var restaurants []models.Restaurant
+    err := db.DB.Select("id, category").
+        Where("category <> '' AND NOT EXISTS (SELECT 1 FROM restaurant_tags rt WHERE rt.restaurant_id = restaurants.id)").
+        Find(&restaurants).Error
+    if err != nil {
+        return err
+    }
+    for _, restaurant := range restaurants {
+        tag, ok := Match(tags, restaurant.Category)
+        if !ok {
+            logger.Log.Warnf("No tag matches category %q of restaurant %d", restaurant.Category, restaurant.ID)
+            continue
+        }
+        if err := db.DB.Model(&restaurant).Association("Tags").Append(&tag); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+    ra, rb := []rune(a), []rune(b)
+    previous := make([]int, len(rb)+1)
+    current := make([]int, len(rb)+1)
+    for j := range previous {
+        previous[j] = j
+    }
+    for i := 1; i <= len(ra); i++ {
+        current[0] = i
+        for j := 1; j <= len(rb); j++ {
+            cost := 1
+            if ra[i-1] == rb[j-1] {
+                cost = 0
+            }
+            current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+        }
+        previous, current = current, previous
+    }
+    return previous[len(rb)]
+}
@@ -0,0 +1,158 @@
+package taxonomy
+
+import (
+    "reflect"
+    "testing"
+
+    "github.com/pp00x/foodiebaba/internal/models"
+)
+
+// defaultTags numbers the default taxonomy the way Seed stores it
+func defaultTags() []models.Tag {
+    var tags []models.Tag
+    var add func(seeds []seedTag, parentID *uint)
+    add = func(seeds []seedTag, parentID *uint) {
+        for _, s := range seeds {
+            id := uint(len(tags) + 1)
+            tags = append(tags, models.Tag{ID: id, Name: s.name, Slug: Slugify(s.name), Kind: s.kind, ParentID: parentID, Aliases: s.aliases})
+            add(s.children, &id)
+        }
+    }
+    add(defaults, nil)
+    return tags
+}
+
+func tagID(t *testing.T, tags []models.Tag, slug string) uint {
+    for _, tag := range tags {
+        if tag.Slug == slug {
+            return tag.ID
+        }
+    }
+    t.Fatalf("no tag %q", slug)
+    return 0
+}
+
+func TestSlugify(t *testing.T) {
+    tests := []struct {
+        name string
+        want string
+    }{
+        {"Middle Eastern", "middle-eastern"},
+        {"  Fast   Food!! ", "fast-food"},
+        {"Tex-Mex & BBQ", "tex-mex-bbq"},
+        {"Café", "café"},
+        {"24/7 Diner", "24-7-diner"},
+        {"", ""},
+    }
+    for _, tt := range tests {
+        if got := Slugify(tt.name); got != tt.want {
+            t.Errorf("Slugify(%q) = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestDescendants(t *testing.T) {
+    tags := defaultTags()
+    tests := []struct {
+        slug string
+        want []string
+    }{
+        {"asian", []string{"asian", "chinese", "japanese", "korean", "thai", "vietnamese", "indian"}},
+        {"european", []string{"european", "italian", "french", "spanish", "greek"}},
+        {"italian", []string{"italian"}},
+        {"bar", []string{"bar"}},
+    }
+    for _, tt := range tests {
+        want := []uint{}
+        for _, slug := range tt.want {
+            want = append(want, tagID(t, tags, slug))
+        }
+        if got := Descendants(tags, tagID(t, tags, tt.slug)); !reflect.DeepEqual(got, want) {
+            t.Errorf("Descendants(%s) = %v, want %v", tt.slug, got, want)
+        }
+    }
+
+    // deeper levels are included too
+    tags = append(tags, models.Tag{ID: 100, Name: "Dim Sum", Slug: "dim-sum", ParentID: ptr(tagID(t, tags, "chinese"))})
+    got := Descendants(tags, tagID(t, tags, "asian"))
+    if got[len(got)-1] != 100 {
+        t.Errorf("Descendants(asian) = %v, missing the grandchild 100", got)
+    }
+
+    if got := Descendants(tags, 999); !reflect.DeepEqual(got, []uint{999}) {
+        t.Errorf("Descendants of an unknown tag = %v, want [999]", got)
+    }
+}
+
+func ptr(id uint) *uint {
+    return &id
+}
+
+func TestMatch(t *testing.T) {
+    tags := defaultTags()
+    tests := []struct {
+        category string
+        want     string // slug, empty when nothing should match
+    }{
+        {"Italian", "italian"},
+        {"ITALIAN", "italian"},
+        {"Italian food", "italian"},
+        {"Thai Cuisine", "thai"},
+        {"middle-eastern", "middle-eastern"},
+        {"Pizza", "italian"},
+        {"Sushi Restaurant", "japanese"},
+        {"Café", "cafe"},
+        {"coffee shop", "cafe"},
+        {"Bar", "bar"},
+        {"BBQ", "american"},
+        {"Itallian", "italian"},
+        {"Mexcan", "mexican"},
+        {"Burgr", "american"},
+        {"Vietnamesee", "vietnamese"},
+        {"Thia", ""},
+        {"Baz", ""},
+        {"Food", ""},
+        {"", ""},
+        {"Xyzzy", ""},
+    }
+    for _, tt := range tests {
+        tag, ok := Match(tags, tt.category)
+        if ok != (tt.want != "") || tag.Slug != tt.want {
+            t.Errorf("Match(%q) = %q, %v, want %q", tt.category, tag.Slug, ok, tt.want)
+        }
+    }
+}
+
+func TestMatchPrefersExactMatches(t *testing.T) {
+    // "bistros" is within one typo of "bistro" and comes first
+    tags := []models.Tag{
+        {ID: 1, Name: "Bistros", Slug: "bistros"},
+        {ID: 2, Name: "Bistro", Slug: "bistro"},
+    }
+    if tag, ok := Match(tags, "bistro"); !ok || tag.ID != 2 {
+        t.Errorf("Match(bistro) = %d, %v, want 2", tag.ID, ok)
+    }
+    if tag, ok := Match(tags, "bistros"); !ok || tag.ID != 1 {
+        t.Errorf("Match(bistros) = %d, %v, want 1", tag.ID, ok)
+    }
+}
+
+func TestLevenshtein(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want int
+    }{
+        {"", "", 0},
+        {"abc", "", 3},
+        {"", "abc", 3},
+        {"thai", "thai", 0},
+        {"thai", "thia", 2},
+        {"kitten", "sitting", 3},
+        {"café", "cafe", 1},
+    }
+    for _, tt := range tests {
+        if got := levenshtein(tt.a, tt.b); got != tt.want {
+            t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/dgrijalva/jwt-go"
+    "github.com/pp00x/foodiebaba/configs"
+    "github.com/pp00x/foodiebaba/internal/keys"
+)
+
+// TokenTypeMFA marks challenge tokens issued between the password and second factor steps
+const TokenTypeMFA = "mfa"
+
+// AccessTokenTTL returns how long an access token stays valid
+func AccessTokenTTL() time.Duration {
+    return configs.GetDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// RefreshTokenTTL returns how long a refresh token stays valid
+func RefreshTokenTTL() time.Duration {
+    return configs.GetDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+// MFAChallengeTTL returns how long a user has to enter their second factor after a password login
+func MFAChallengeTTL() time.Duration {
+    return configs.GetDuration("MFA_CHALLENGE_TTL", 5*time.Minute)
+}
+
+// GenerateAccessToken signs a short-lived JWT for the given user.
+// mfa records whether the login completed a second factor.
+func GenerateAccessToken(userID uint, role string, mfa bool) (string, error) {
+    now := time.Now()
+    return keys.Sign(jwt.MapClaims{
+        "iss":     TokenIssuer(),
+        "user_id": userID,
+        "role":    role,
+        "mfa":     mfa,
+        "iat":     now.Unix(),
+        "exp":     now.Add(AccessTokenTTL()).Unix(),
+    })
+}
+
+// GenerateMFAToken signs a challenge token proving the password step of a login succeeded.
+// It cannot be used as an access token.
+func GenerateMFAToken(userID uint) (string, error) {
+    return keys.Sign(jwt.MapClaims{
+        "iss":     TokenIssuer(),
+        "user_id": userID,
+        "type":    TokenTypeMFA,
+        "exp":     time.Now().Add(MFAChallengeTTL()).Unix(),
+    })
+}
+
+// TokenIssuer is the iss claim of the tokens we sign
+func TokenIssuer() string {
+    return configs.GetEnv("JWT_ISSUER", "foodiebaba")
+}
+
+// ParseToken verifies the signature and expiry of a JWT and returns its claims
+func ParseToken(tokenString string) (jwt.MapClaims, error) {
+    token, err := jwt.Parse(tokenString, keys.Keyfunc)
+    if err != nil {
+        return nil, err
+    }
+    claims, ok := token.Claims.(jwt.MapClaims)
+    if !ok || !token.Valid {
+        return nil, fmt.Errorf("invalid token")
+    }
+    // Legacy HS256 tokens predate the iss claim
+    if iss, ok := claims["iss"]; ok && iss != TokenIssuer() {
+        return nil, fmt.Errorf("invalid token issuer")
+    }
+    return claims, nil
+}
+
+// ParseMFAToken returns the user ID of a valid MFA challenge token
+func ParseMFAToken(tokenString string) (uint, error) {
+    claims, err := ParseToken(tokenString)
+    if err != nil {
+        return 0, err
+    }
+    userID, ok := claims["user_id"].(float64)
+    if claims["type"] != TokenTypeMFA || !ok {
+        return 0, fmt.Errorf("not an MFA challenge token")
+    }
+    return uint(userID), nil
+}
+
+// APIKeyPrefix starts every personal API key so they are easy to recognize
+const APIKeyPrefix = "fbk_"
+
+// GenerateAPIKey returns a new key of the form fbk_<prefix>_<secret> and its prefix
+func GenerateAPIKey() (key, prefix string, err error) {
+    b := make([]byte, 4)
+    if _, err := rand.Read(b); err != nil {
+        return "", "", err
+    }
+    prefix = hex.EncodeToString(b)
+    secret, err := GenerateRandomToken(32)
+    if err != nil {
+        return "", "", err
+    }
+    return APIKeyPrefix + prefix + "_" + secret, prefix, nil
+}
+
+// ParseAPIKey splits a key into its prefix, reporting false when it is not an API key
+func ParseAPIKey(key string) (prefix string, ok bool) {
+    if !strings.HasPrefix(key, APIKeyPrefix) {
+        return "", false
+    }
+    parts := strings.SplitN(strings.TrimPrefix(key, APIKeyPrefix), "_", 2)
+    if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+        return "", false
+    }
+    return parts[0], true
+}
+
+// GenerateRandomToken returns a URL-safe random string built from n random bytes
+func GenerateRandomToken(n int) (string, error) {
+    b := make([]byte, n)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of an opaque token so it can be stored at rest
+func HashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestHashToken(t *testing.T) {
+    tests := []struct {
+        token string
+        want  string
+    }{
+        {"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+        {"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+    }
+    for _, tt := range tests {
+        if got := HashToken(tt.token); got != tt.want {
+            t.Errorf("HashToken(%q) = %s, want %s", tt.token, got, tt.want)
+        }
+    }
+}
+
+func TestGenerateRandomToken(t *testing.T) {
+    tests := []struct {
+        n      int
+        length int
+    }{
+        {16, 22},
+        {32, 43},
+        {48, 64},
+    }
+    for _, tt := range tests {
+        a, err := GenerateRandomToken(tt.n)
+        if err != nil {
+            t.Fatalf("GenerateRandomToken(%d): %v", tt.n, err)
+        }
+        b, err := GenerateRandomToken(tt.n)
+        if err != nil {
+            t.Fatalf("GenerateRandomToken(%d): %v", tt.n, err)
+        }
+        if len(a) != tt.length {
+            t.Errorf("GenerateRandomToken(%d) has length %d, want %d", tt.n, len(a), tt.length)
+        }
+        if a == b {
+            t.Errorf("GenerateRandomToken(%d) returned %q twice", tt.n, a)
+        }
+        if strings.ContainsAny(a, "+/=") {
+            t.Errorf("GenerateRandomToken(%d) = %q is not URL-safe", tt.n, a)
+        }
+    }
+}
+
+func TestParseAPIKey(t *testing.T) {
+    tests := []struct {
+        key    string
+        prefix string
+        ok     bool
+    }{
+        {"fbk_0a1b2c3d_secret", "0a1b2c3d", true},
+        {"fbk_0a1b2c3d_sec_ret", "0a1b2c3d", true},
+        {"fbk_0a1b2c3d_", "", false},
+        {"fbk__secret", "", false},
+        {"fbk_0a1b2c3d", "", false},
+        {"eyJhbGciOiJFZERTQSJ9.e30.sig", "", false},
+        {"", "", false},
+    }
+    for _, tt := range tests {
+        prefix, ok := ParseAPIKey(tt.key)
+        if prefix != tt.prefix || ok != tt.ok {
+            t.Errorf("ParseAPIKey(%q) = %q, %v, want %q, %v", tt.key, prefix, ok, tt.prefix, tt.ok)
+        }
+    }
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+    key, prefix, err := GenerateAPIKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(prefix) != 8 {
+        t.Errorf("prefix %q has length %d, want 8", prefix, len(prefix))
+    }
+    parsed, ok := ParseAPIKey(key)
+    if !ok || parsed != prefix {
+        t.Errorf("ParseAPIKey(%q) = %q, %v, want %q, true", key, parsed, ok, prefix)
+    }
+    other, _, err := GenerateAPIKey()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if other == key {
+        t.Errorf("GenerateAPIKey returned %q twice", key)
+    }
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+// Secret "12345678901234567890" from the RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+    tests := []struct {
+        unix int64
+        want string
+    }{
+        {59, "287082"},
+        {1111111109, "081804"},
+        {1111111111, "050471"},
+        {1234567890, "005924"},
+        {2000000000, "279037"},
+    }
+    for _, tt := range tests {
+        got, err := TOTPCode(rfcSecret, tt.unix/totpPeriod)
+        if err != nil {
+            t.Fatalf("TOTPCode at %d: %v", tt.unix, err)
+        }
+        if got != tt.want {
+            t.Errorf("TOTPCode at %d = %s, want %s", tt.unix, got, tt.want)
+        }
+    }
+}
+
+func TestTOTPCodeInvalidSecret(t *testing.T) {
+    if _, err := TOTPCode("not base32!", 1); err == nil {
+        t.Error("TOTPCode accepted an invalid secret")
+    }
+}
+
+func TestValidateTOTP(t *testing.T) {
+    now := time.Unix(1111111111, 0)
+    current := now.Unix() / totpPeriod
+    code := func(step int64) string {
+        c, err := TOTPCode(rfcSecret, step)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return c
+    }
+
+    tests := []struct {
+        name     string
+        code     string
+        wantStep int64
+        wantOK   bool
+    }{
+        {"current step", code(current), current, true},
+        {"previous step", code(current - 1), current - 1, true},
+        {"next step", code(current + 1), current + 1, true},
+        {"two steps behind", code(current - 2), 0, false},
+        {"two steps ahead", code(current + 2), 0, false},
+        {"spaces", code(current)[:3] + " " + code(current)[3:], current, true},
+        {"surrounding whitespace", " " + code(current) + "\n", current, true},
+        {"too short", code(current)[:5], 0, false},
+        {"too long", code(current) + "0", 0, false},
+        {"empty", "", 0, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            step, ok := ValidateTOTP(rfcSecret, tt.code, now)
+            if ok != tt.wantOK || step != tt.wantStep {
+                t.Errorf("ValidateTOTP(%q) = %d, %v, want %d, %v", tt.code, step, ok, tt.wantStep, tt.wantOK)
+            }
+        })
+    }
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+    secret, err := GenerateTOTPSecret()
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(secret) != 32 {
+        t.Errorf("secret %q has length %d, want 32", secret, len(secret))
+    }
+    if _, err := TOTPCode(secret, 1); err != nil {
+        t.Errorf("generated secret cannot be used: %v", err)
+    }
+}
+
+func TestTOTPURI(t *testing.T) {
+    uri := TOTPURI("Foodie Baba", "ana@example.com", rfcSecret)
+    for _, part := range []string{"otpauth://totp/Foodie%20Baba:ana@example.com?", "secret=" + rfcSecret, "digits=6", "period=30", "issuer=Foodie+Baba"} {
+        if !strings.Contains(uri, part) {
+            t.Errorf("TOTPURI = %s, missing %s", uri, part)
+        }
+    }
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/pp00x/foodiebaba/configs"
+)
+
+// Message is a plain text email
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+    Send(msg Message) error
+}
+
+var Default Mailer
+
+// Init configures the global mailer from MAIL_DRIVER ("smtp" or "outbox")
+func Init() {
+    from := configs.GetEnv("MAIL_FROM", "FoodieBaba <no-reply@foodiebaba.com>")
+    switch configs.GetEnv("MAIL_DRIVER", "outbox") {
+    case "smtp":
+        Default = &SMTPMailer{
+            Host:     configs.GetEnv("SMTP_HOST", "localhost"),
+            Port:     configs.GetEnv("SMTP_PORT", "587"),
+            Username: configs.GetEnv("SMTP_USERNAME", ""),
+            Password: configs.GetEnv("SMTP_PASSWORD", ""),
+            From:     from,
+        }
+    default:
+        Default = &OutboxMailer{
+            Dir:  configs.GetEnv("MAIL_OUTBOX_DIR", "outbox"),
+            From: from,
+        }
+    }
+}
+
+// Send delivers the message through the global mailer
+func Send(msg Message) error {
+    if Default == nil {
+        return fmt.Errorf("mailer not initialized")
+    }
+    return Default.Send(msg)
+}
+
+// format renders the message as an RFC 5322 email
+func format(from string, msg Message) []byte {
+    var b strings.Builder
+    fmt.Fprintf(&b, "From: %s\r\n", from)
+    fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+    fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+    b.WriteString("\r\n")
+    b.WriteString(msg.Body)
+    return []byte(b.String())
+}
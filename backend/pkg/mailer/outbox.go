@@ -0,0 +1,26 @@
+package mailer
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// OutboxMailer writes every message to a .eml file instead of sending it.
+// Useful for local development and tests.
+type OutboxMailer struct {
+    Dir  string
+    From string
+}
+
+func (m *OutboxMailer) Send(msg Message) error {
+    if err := os.MkdirAll(m.Dir, os.ModePerm); err != nil {
+        return err
+    }
+    name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+    return os.WriteFile(filepath.Join(m.Dir, name), format(m.From, msg), 0o600)
+}
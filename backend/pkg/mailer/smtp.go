@@ -0,0 +1,29 @@
+package mailer
+
+import (
+    "net"
+    "net/mail"
+    "net/smtp"
+)
+
+// SMTPMailer sends email through an SMTP relay
+type SMTPMailer struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+    sender, err := mail.ParseAddress(m.From)
+    if err != nil {
+        return err
+    }
+
+    var auth smtp.Auth
+    if m.Username != "" {
+        auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+    }
+    return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, sender.Address, []string{msg.To}, format(m.From, msg))
+}